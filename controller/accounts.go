@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//forgotReply and resendReply are the same whether or not the address has an account, so they can't be used to find accounts
+const (
+	forgotReply = "If the address belongs to an account, a reset link is on its way"
+	resendReply = "If the address belongs to an unverified account, a verification link is on its way"
+)
+
+//AccountsController lets users recover their account through the mails they get ...
+type AccountsController struct {
+	conf      utils.AccountConf
+	resetTTL  time.Duration
+	verifyTTL time.Duration
+}
+
+//NewAccountsController ...
+func NewAccountsController(conf utils.AccountConf) *AccountsController {
+	//already checked with the configuration
+	resetTTL, _ := time.ParseDuration(conf.ResetTTL)
+	verifyTTL, _ := time.ParseDuration(conf.VerifyTTL)
+
+	return &AccountsController{conf: conf, resetTTL: resetTTL, verifyTTL: verifyTTL}
+}
+
+//ForgotPassword mails a single use reset token to the owner of the address ...
+func (ac *AccountsController) ForgotPassword(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Email == "" {
+		utils.WriteJSON(w, "Body must carry an email", http.StatusBadRequest)
+		return
+	}
+
+	ctx := utils.RequestContext(r)
+
+	user, err := model.GetUserByEmail(ctx, body.Email)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, forgotReply, http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("looking up user")
+		utils.WriteJSON(w, "Unable to reset the password", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := utils.RandomToken(32)
+	if err == nil {
+		now := time.Now()
+		err = model.CreatePasswordReset(ctx, user.ID, token, now, now.Add(ac.resetTTL))
+	}
+
+	//the reply stays the same, failures only show in the log
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("creating password reset")
+		utils.WriteJSON(w, forgotReply, http.StatusAccepted)
+		return
+	}
+
+	utils.SendMail(ctx, utils.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    ac.resetBody(user, token),
+	})
+
+	utils.WriteJSON(w, forgotReply, http.StatusAccepted)
+}
+
+func (ac *AccountsController) resetBody(user model.User, token string) string {
+	body := "Hi " + user.Username + ",\n\nsomeone asked to reset the password of your account. "
+
+	if ac.conf.ResetURL != "" {
+		body += "Follow this link to choose a new one:\n\n" + ac.conf.ResetURL + token
+	} else {
+		body += "Use this token to choose a new one:\n\n" + token
+	}
+
+	return body + "\n\nIt works once, for the next " + ac.resetTTL.String() +
+		". If you didn't ask for it, you can ignore this mail.\n"
+}
+
+//ResetPassword sets the new password for the owner of the token and logs out all of their sessions ...
+func (ac *AccountsController) ResetPassword(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Token == "" {
+		utils.WriteJSON(w, "Body must carry a token and a password", http.StatusBadRequest)
+		return
+	}
+
+	if len(body.Password) <= 4 {
+		utils.WriteJSON(w, "Password must be longer than 4 characters", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := model.ResetPassword(utils.RequestContext(r), body.Token, body.Password, time.Now())
+
+	if err == model.ErrResetTokenInvalid {
+		utils.WriteJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("resetting password")
+		utils.WriteJSON(w, "Unable to reset the password", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RequestLogger(r).WithField("userID", userID).Info("password reset")
+
+	utils.WriteJSON(w, "Password changed, log in again", http.StatusOK)
+}
+
+//SendVerification mails a token verifying email for user, email is the new address on a change ...
+func (ac *AccountsController) SendVerification(ctx context.Context, user model.User, email string) error {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	err = model.CreateEmailVerification(ctx, user.ID, email, token, now, now.Add(ac.verifyTTL))
+	if err != nil {
+		return err
+	}
+
+	body := "Hi " + user.Username + ",\n\nplease confirm that " + email + " is your address. "
+
+	if ac.conf.VerifyURL != "" {
+		body += "Follow this link:\n\n" + ac.conf.VerifyURL + token
+	} else {
+		body += "Use this token:\n\n" + token
+	}
+
+	utils.SendMail(ctx, utils.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body:    body + "\n\nIt works for the next " + ac.verifyTTL.String() + ".\n",
+	})
+
+	return nil
+}
+
+//VerifyEmail spends a verification token, the address it was sent to becomes the verified email of the user ...
+func (ac *AccountsController) VerifyEmail(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Token == "" {
+		utils.WriteJSON(w, "Body must carry a token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := model.VerifyEmail(utils.RequestContext(r), body.Token, time.Now())
+
+	switch err {
+	case nil:
+	case model.ErrVerificationTokenInvalid:
+		utils.WriteJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	case model.ErrEmailTaken:
+		utils.WriteJSON(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		utils.RequestLogger(r).WithError(err).Error("verifying email")
+		utils.WriteJSON(w, "Unable to verify the email", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RequestLogger(r).WithField("userID", user.ID).Info("email verified")
+
+	utils.WriteJSON(w, "Email verified", http.StatusOK)
+}
+
+//ResendVerification mails a new verification token to an unverified account, earlier ones stop working ...
+func (ac *AccountsController) ResendVerification(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Email == "" {
+		utils.WriteJSON(w, "Body must carry an email", http.StatusBadRequest)
+		return
+	}
+
+	ctx := utils.RequestContext(r)
+
+	user, err := model.GetUserByEmail(ctx, body.Email)
+	if err == sql.ErrNoRows || (err == nil && user.EmailVerified != 0) {
+		utils.WriteJSON(w, resendReply, http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("looking up user")
+		utils.WriteJSON(w, "Unable to send the verification", http.StatusInternalServerError)
+		return
+	}
+
+	err = ac.SendVerification(ctx, user, user.Email)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("creating email verification")
+	}
+
+	utils.WriteJSON(w, resendReply, http.StatusAccepted)
+}
+
+//UpdateEmail asks to verify a new address, the account keeps the old one until it is verified ...
+func (ac *AccountsController) UpdateEmail(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || !emailFormat.MatchString(body.Email) {
+		utils.WriteJSON(w, "Email is empty or wrong email format", http.StatusBadRequest)
+		return
+	}
+
+	ctx := utils.RequestContext(r)
+
+	other, err := model.GetUserByEmail(ctx, body.Email)
+	if err == nil && other.ID != user.ID {
+		utils.WriteJSON(w, model.ErrEmailTaken.Error(), http.StatusConflict)
+		return
+	}
+	if err == nil && user.EmailVerified != 0 {
+		utils.WriteJSON(w, "Email is already verified", http.StatusOK)
+		return
+	}
+	if err != nil && err != sql.ErrNoRows {
+		utils.RequestLogger(r).WithError(err).Error("looking up user")
+		utils.WriteJSON(w, "Unable to change the email", http.StatusInternalServerError)
+		return
+	}
+
+	err = ac.SendVerification(ctx, user, body.Email)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("creating email verification")
+		utils.WriteJSON(w, "Unable to change the email", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, "Verify the new address to finish the change", http.StatusAccepted)
+}
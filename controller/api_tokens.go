@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//CreateAPIToken creates a personal access token, the token itself is only in this response ...
+func (uc Users) CreateAPIToken(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	var body struct {
+		Name    string   `json:"name"`
+		Scopes  []string `json:"scopes"`
+		Expires int64    `json:"expires"` //unix time, 0 never expires
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		utils.WriteJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	body.Name = strings.TrimSpace(body.Name)
+
+	if body.Name == "" || len(body.Name) > 100 {
+		utils.WriteJSON(w, "Name must have 1 to 100 characters", http.StatusBadRequest)
+		return
+	}
+	if len(body.Scopes) == 0 {
+		utils.WriteJSON(w, "Give the token at least one scope of "+strings.Join(model.KnownScopes, ", "), http.StatusBadRequest)
+		return
+	}
+	for _, scope := range body.Scopes {
+		if !model.Scopes(model.KnownScopes).Has(scope) {
+			utils.WriteJSON(w, "Unknown scope "+scope+", scopes are "+strings.Join(model.KnownScopes, ", "), http.StatusBadRequest)
+			return
+		}
+	}
+	if body.Expires != 0 && body.Expires <= now.Unix() {
+		utils.WriteJSON(w, "Expires must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		utils.WriteJSON(w, "Unable to create the token", http.StatusInternalServerError)
+		return
+	}
+
+	token := model.APIToken{
+		UserID:  user.ID,
+		Name:    body.Name,
+		Scopes:  model.Scopes(body.Scopes),
+		Created: now.Unix(),
+		Expires: body.Expires,
+		Token:   model.APITokenPrefix + secret,
+	}
+
+	err = model.CreateAPIToken(utils.RequestContext(r), &token, token.Token)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("creating API token")
+		utils.WriteJSON(w, "Unable to create the token", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, token, http.StatusCreated)
+}
+
+//ListAPITokens lists the tokens of the caller, without the tokens themselves ...
+func (uc Users) ListAPITokens(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	tokens, err := model.ListAPITokens(utils.RequestContext(r), user.ID)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("listing API tokens")
+		utils.WriteJSON(w, "Error listing tokens", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, tokens, http.StatusOK)
+}
+
+//DeleteAPIToken revokes a token of the caller ...
+func (uc Users) DeleteAPIToken(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		utils.WriteJSON(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	err = model.DeleteAPIToken(utils.RequestContext(r), user.ID, id)
+
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("deleting API token")
+		utils.WriteJSON(w, "Error deleting token", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, "Token revoked", http.StatusOK)
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//maxBatchOps limits the size of a single batch request ...
+const maxBatchOps = 500
+
+//batchRequest is the body of POST /batch ...
+type batchRequest struct {
+	Mode       string          `json:"mode"` //atomic (default) or best-effort
+	Operations []model.BatchOp `json:"operations"`
+}
+
+//batchOpResult ...
+type batchOpResult struct {
+	model.BatchResult
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+//Batch runs an ordered list of task operations in one transaction ...
+func (tdc ToDoController) Batch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	var req batchRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), 400)
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = "atomic"
+	}
+
+	if req.Mode != "atomic" && req.Mode != "best-effort" {
+		utils.WriteJSON(w, "Mode must be atomic or best-effort", 400)
+		return
+	}
+
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOps {
+		utils.WriteJSON(w, fmt.Sprintf("A batch must contain between 1 and %d operations", maxBatchOps), 400)
+		return
+	}
+
+	results, committed, err := model.RunBatch(utils.RequestContext(r), user, req.Operations, req.Mode == "atomic")
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("running batch")
+		utils.WriteJSON(w, "Batch failed", 500)
+		return
+	}
+
+	status := 200
+	response := make([]batchOpResult, len(results))
+
+	for i, result := range results {
+		response[i] = batchOpResult{BatchResult: result, Status: batchStatus(result.Err)}
+
+		if result.Err != nil {
+			response[i].Error = result.Err.Error()
+
+			if !committed && result.Err != model.ErrBatchSkipped {
+				status = response[i].Status
+			}
+		}
+	}
+
+	if committed {
+		publishBatch(utils.RequestContext(r), results)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"mode":      req.Mode,
+		"committed": committed,
+		"results":   response,
+	}, status)
+}
+
+func batchStatus(err error) int {
+	switch err {
+	case nil:
+		return 200
+	case model.ErrListForbidden:
+		return 403
+	case sql.ErrNoRows:
+		return 404
+	case model.ErrBatchSkipped:
+		return 424
+	}
+
+	return 400
+}
+
+//publishBatch sends events for operations that were committed ...
+func publishBatch(ctx context.Context, results []model.BatchResult) {
+	for _, result := range results {
+		if result.Err != nil || result.Task == nil {
+			continue
+		}
+
+		switch result.Op {
+		case "create":
+			model.PublishTaskEvent(ctx, "task.created", result.Task.ToDoID, result.Task.ID)
+		case "update":
+			model.PublishTaskEvent(ctx, "task.updated", result.Task.ToDoID, result.Task.ID)
+		case "delete":
+			if todo, err := model.GetAnyToDo(ctx, result.Task.ToDoID); err == nil {
+				model.PublishToDoEvent("task.deleted", todo, *result.Task)
+			}
+		case "move":
+			if todo, err := model.GetAnyToDo(ctx, result.FromToDoID); err == nil {
+				model.PublishToDoEvent("task.moved", todo, *result.Task)
+			}
+			if todo, err := model.GetAnyToDo(ctx, result.Task.ToDoID); err == nil {
+				model.PublishToDoEvent("task.moved", todo, *result.Task)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+)
+
+//CreateFeed creates or rotates the secret calendar feed of a list ...
+func (tdc ToDoController) CreateFeed(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	todoID, err := strconv.Atoi(params.ByName("id"))
+
+	feed, err := model.NewFeedToken(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("creating calendar feed")
+		utils.WriteJSON(w, "Unable to create calendar feed", 500)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"todoID": feed.ToDoID,
+		"token":  feed.Token,
+		"path":   "/calendar/" + feed.Token + ".ics",
+	}, 200)
+}
+
+//DeleteFeed ...
+func (tdc ToDoController) DeleteFeed(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	todoID, err := strconv.Atoi(params.ByName("id"))
+
+	err = model.DeleteFeedToken(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 500)
+		return
+	}
+
+	utils.WriteJSON(w, "Calendar feed disabled", 200)
+}
+
+//Calendar renders the tasks of a list as an RFC 5545 VCALENDAR, authorized by the feed token ...
+func (tdc ToDoController) Calendar(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	token := strings.TrimSuffix(params.ByName("token"), ".ics")
+
+	todo, err := model.GetToDoByFeedToken(utils.RequestContext(r), token)
+	if err == model.ErrFeedNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("loading calendar feed")
+		utils.WriteJSON(w, "Unable to load calendar", 500)
+		return
+	}
+
+	tasks, err := model.ListTasks(utils.RequestContext(r), todo.ID)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("loading calendar tasks")
+		utils.WriteJSON(w, "Unable to load calendar", 500)
+		return
+	}
+
+	var b strings.Builder
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	icalLine(&b, "BEGIN:VCALENDAR")
+	icalLine(&b, "VERSION:2.0")
+	icalLine(&b, "PRODID:-//bicom//todos//EN")
+	icalLine(&b, "CALSCALE:GREGORIAN")
+	icalLine(&b, "X-WR-CALNAME:"+icalEscape(todo.Name))
+
+	for _, task := range tasks {
+		icalLine(&b, "BEGIN:VTODO")
+		icalLine(&b, fmt.Sprintf("UID:task-%d-todo-%d@todos", task.ID, todo.ID))
+		icalLine(&b, "DTSTAMP:"+stamp)
+		icalLine(&b, "SUMMARY:"+icalEscape(task.Name))
+
+		if created, ok := icalCreated(task.DateCreated); ok {
+			icalLine(&b, "CREATED:"+created)
+		}
+		if due, ok := icalDate(task.DateFinish); ok {
+			icalLine(&b, "DUE"+due)
+		}
+		if priority := icalPriority(task.Priority); priority > 0 {
+			icalLine(&b, "PRIORITY:"+strconv.Itoa(priority))
+		}
+
+		if task.Status {
+			icalLine(&b, "STATUS:COMPLETED")
+		} else {
+			icalLine(&b, "STATUS:NEEDS-ACTION")
+		}
+
+		icalLine(&b, "END:VTODO")
+	}
+
+	icalLine(&b, "END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=todo-"+strconv.Itoa(todo.ID)+".ics")
+	w.WriteHeader(200)
+	w.Write([]byte(b.String()))
+}
+
+//icalLine writes a content line folded at 75 octets, as RFC 5545 requires ...
+func icalLine(b *strings.Builder, line string) {
+	limit := 75
+
+	for len(line) > limit {
+		cut := limit
+		//don't split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		//the leading space of a continuation counts too
+		limit = 74
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+//icalDate formats a task date as UTC when it has an offset, and as floating local time otherwise ...
+func icalDate(value string) (string, bool) {
+	t, hasTime, ok := utils.ParseDate(value)
+	if !ok {
+		return "", false
+	}
+
+	if !hasTime {
+		return ";VALUE=DATE:" + t.Format("20060102"), true
+	}
+
+	if utils.HasZone(value) {
+		return ":" + t.UTC().Format("20060102T150405Z"), true
+	}
+
+	return ":" + t.Format("20060102T150405"), true
+}
+
+//icalCreated formats the creation date for CREATED, which only takes a UTC date-time, values without an
+//offset are read in the server's zone like the rest of the Task dates ...
+func icalCreated(value string) (string, bool) {
+	t, _, ok := utils.ParseDate(value)
+	if !ok {
+		return "", false
+	}
+
+	return t.UTC().Format("20060102T150405Z"), true
+}
+
+//icalPriority maps task priority 1 (highest) - 5 onto the iCalendar 1-9 scale ...
+func icalPriority(priority string) int {
+	p, err := strconv.Atoi(strings.TrimSpace(priority))
+	if err != nil || p < 1 || p > 5 {
+		return 0
+	}
+
+	return 2*p - 1
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/bicom/todos/docs"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/julienschmidt/httprouter"
+)
+
+//DocsController serves the OpenAPI description of the API ...
+type DocsController struct {
+	doc  *openapi3.T
+	spec []byte
+}
+
+//NewDocsController parses the embedded OpenAPI document and checks that it is valid ...
+func NewDocsController() (*DocsController, error) {
+	spec := docs.OpenAPI
+
+	loader := openapi3.NewLoader()
+
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = doc.Validate(loader.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DocsController{doc: doc, spec: spec}, nil
+}
+
+//Doc returns the parsed document for the validation middleware ...
+func (dc *DocsController) Doc() *openapi3.T {
+	return dc.doc
+}
+
+//OpenAPI ...
+func (dc *DocsController) OpenAPI(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(dc.spec)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//heartbeatInterval keeps proxies from closing idle streams ...
+var heartbeatInterval = 15 * time.Second
+
+//EventsController ...
+type EventsController struct{}
+
+//Stream sends live changes as Server-Sent Events ...
+func (ec EventsController) Stream(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteJSON(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID := lastEventID(r)
+
+	sub := utils.Events.Subscribe(func(e utils.Event) bool {
+		return canSeeEvent(user, e)
+	})
+	defer utils.Events.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", 3000)
+
+	if lastID > 0 {
+		for _, e := range utils.Events.Since(lastID) {
+			if !canSeeEvent(user, e) {
+				continue
+			}
+			if writeEvent(w, e) != nil {
+				return
+			}
+			lastID = e.ID
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	//like sockets, streams end once their session or API token is revoked
+	recheck := time.NewTicker(socketRecheck)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				//dropped by the hub, client resumes with Last-Event-ID
+				return
+			}
+			if e.ID <= lastID {
+				continue
+			}
+			if writeEvent(w, e) != nil {
+				return
+			}
+			lastID = e.ID
+			flusher.Flush()
+		case <-recheck.C:
+			err := model.CheckCredential(utils.RequestContext(r), user, time.Now())
+			if credentialEnded(err) {
+				return
+			}
+			if err != nil {
+				utils.RequestLogger(r).WithError(err).Error("stream credential check")
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func lastEventID(r *http.Request) int64 {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
+
+func canSeeEvent(user model.User, e utils.Event) bool {
+	return user.IsAdmin() || e.UserID == user.ID
+}
+
+func writeEvent(w http.ResponseWriter, e utils.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+
+	return err
+}
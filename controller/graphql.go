@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/julienschmidt/httprouter"
+)
+
+//graphQLSchema ...
+var graphQLSchema = `
+	schema {
+		query: Query
+		mutation: Mutation
+	}
+
+	type Query {
+		me: User!
+		# admin only
+		users: [User!]!
+		user(id: ID!): User!
+		# own lists, admin gets all of them
+		todos: [ToDo!]!
+		todo(id: ID!): ToDo!
+		task(id: ID!): Task!
+	}
+
+	type Mutation {
+		createToDo(name: String!, description: String): ToDo!
+		updateToDo(id: ID!, name: String, description: String): ToDo!
+		deleteToDo(id: ID!): Boolean!
+		createTask(todoID: ID!, input: TaskInput!): Task!
+		updateTask(id: ID!, input: TaskInput!): Task!
+		deleteTask(id: ID!): Boolean!
+		updatePassword(oldpass: String!, newpass: String!): Boolean!
+		# admin only
+		updateUserType(id: ID!, type: String!): Boolean!
+		# admin only
+		deleteUser(id: ID!): Boolean!
+	}
+
+	type User {
+		id: ID!
+		type: String!
+		username: String!
+		firstname: String!
+		lastname: String!
+		email: String!
+		todos: [ToDo!]!
+	}
+
+	type ToDo {
+		id: ID!
+		name: String!
+		description: String!
+		owner: User
+		tasks(completed: Boolean): [Task!]!
+	}
+
+	type Task {
+		id: ID!
+		name: String!
+		dateCreated: String!
+		dateFinish: String!
+		# empty while open
+		dateDone: String!
+		# 1 (highest) - 5
+		priority: Int
+		status: Boolean!
+		todo: ToDo
+	}
+
+	input TaskInput {
+		name: String
+		dateCreated: String
+		dateFinish: String
+		priority: Int
+		status: Boolean
+	}
+`
+
+//graphQLMaxDepth stops queries that nest todo, tasks and owner into each other without end, and
+//graphQLMaxParallelism bounds the resolvers one query runs at once
+const (
+	graphQLMaxDepth       = 8
+	graphQLMaxParallelism = 10
+	maxGraphQLBody        = 1 << 20 //a query with its variables
+)
+
+//GraphQLController serves /graphql ...
+type GraphQLController struct {
+	schema  *graphql.Schema
+	allowed func(user model.User, path string, mode string) bool
+}
+
+//NewGraphQLController parses the schema, allowed applies the RBAC rules of Provider.JWT ...
+func NewGraphQLController(allowed func(user model.User, path string, mode string) bool) *GraphQLController {
+	return &GraphQLController{
+		schema: graphql.MustParseSchema(graphQLSchema, &graphQLRoot{},
+			graphql.MaxDepth(graphQLMaxDepth), graphql.MaxParallelism(graphQLMaxParallelism)),
+		allowed: allowed,
+	}
+}
+
+//Serve executes a GraphQL request ...
+func (gc *GraphQLController) Serve(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBody)).Decode(&params)
+	if _, tooLarge := err.(*http.MaxBytesError); tooLarge {
+		utils.WriteJSON(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), 400)
+		return
+	}
+
+	ctx := context.WithValue(utils.RequestContext(r), graphQLRequestKey{}, newGraphQLRequest(user, gc.allowed))
+
+	response := gc.schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+
+	utils.WriteJSON(w, response, 200)
+}
@@ -0,0 +1,825 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/bicom/todos/model"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+var (
+	errGraphQLForbidden = errors.New("Acces Forbidden")
+	errGraphQLAdmin     = errors.New("Only admins can do this")
+	errGraphQLID        = errors.New("Invalid ID")
+)
+
+type graphQLRequestKey struct{}
+
+//graphQLRequest holds the caller and the batch loaders of one request ...
+type graphQLRequest struct {
+	user    model.User
+	allowed func(user model.User, path string, mode string) bool
+
+	tasks     *batchLoader //ToDo ID -> []model.Task
+	todos     *batchLoader //ToDo ID -> model.ToDo
+	users     *batchLoader //user ID -> model.User
+	userTodos *batchLoader //user ID -> []model.ToDo
+}
+
+func newGraphQLRequest(user model.User, allowed func(model.User, string, string) bool) *graphQLRequest {
+	return &graphQLRequest{
+		user:    user,
+		allowed: allowed,
+		tasks: newBatchLoader(func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+			tasks, err := model.ListTasksForToDos(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			grouped := make(map[int]interface{})
+			for _, id := range ids {
+				grouped[id] = []model.Task{}
+			}
+			for _, task := range tasks {
+				grouped[task.ToDoID] = append(grouped[task.ToDoID].([]model.Task), task)
+			}
+
+			return grouped, nil
+		}),
+		todos: newBatchLoader(func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+			todos, err := model.GetToDos(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[int]interface{})
+			for _, todo := range todos {
+				byID[todo.ID] = todo
+			}
+
+			return byID, nil
+		}),
+		users: newBatchLoader(func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+			users, err := model.GetUsers(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[int]interface{})
+			for _, user := range users {
+				byID[user.ID] = user
+			}
+
+			return byID, nil
+		}),
+		userTodos: newBatchLoader(func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+			todos, err := model.ListToDosForUsers(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			grouped := make(map[int]interface{})
+			for _, id := range ids {
+				grouped[id] = []model.ToDo{}
+			}
+			for _, todo := range todos {
+				grouped[todo.UserID] = append(grouped[todo.UserID].([]model.ToDo), todo)
+			}
+
+			return grouped, nil
+		}),
+	}
+}
+
+func requestFrom(ctx context.Context) *graphQLRequest {
+	return ctx.Value(graphQLRequestKey{}).(*graphQLRequest)
+}
+
+//authorize applies the same RBAC rule Provider.JWT applies to the REST path ...
+func (req *graphQLRequest) authorize(path string, mode string) error {
+	if req.allowed != nil && !req.allowed(req.user, path, mode) {
+		return errGraphQLForbidden
+	}
+
+	return nil
+}
+
+//batchLoader collects IDs and loads all pending ones with a single query on first use,
+//so resolving a field on every element of a list costs one query instead of N ...
+type batchLoader struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, ids []int) (map[int]interface{}, error)
+	pending map[int]bool
+	cache   map[int]interface{}
+}
+
+func newBatchLoader(fetch func(ctx context.Context, ids []int) (map[int]interface{}, error)) *batchLoader {
+	return &batchLoader{
+		fetch:   fetch,
+		pending: make(map[int]bool),
+		cache:   make(map[int]interface{}),
+	}
+}
+
+//prime queues IDs which are about to be loaded ...
+func (l *batchLoader) prime(ids ...int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := l.cache[id]; !ok {
+			l.pending[id] = true
+		}
+	}
+}
+
+//store caches a value which is already known ...
+func (l *batchLoader) store(id int, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache[id] = value
+	delete(l.pending, id)
+}
+
+//load returns the value for id, nil if it doesn't exist ...
+func (l *batchLoader) load(ctx context.Context, id int) (interface{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if value, ok := l.cache[id]; ok {
+		return value, nil
+	}
+
+	l.pending[id] = true
+
+	ids := make([]int, 0, len(l.pending))
+	for pending := range l.pending {
+		ids = append(ids, pending)
+	}
+
+	values, err := l.fetch(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pending := range ids {
+		l.cache[pending] = values[pending]
+	}
+	l.pending = make(map[int]bool)
+
+	return l.cache[id], nil
+}
+
+func parseID(id graphql.ID) (int, error) {
+	value, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, errGraphQLID
+	}
+
+	return value, nil
+}
+
+func graphQLID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func todoResolvers(req *graphQLRequest, todos []model.ToDo) []*todoResolver {
+	resolvers := make([]*todoResolver, 0, len(todos))
+
+	var ids, owners []int
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+		owners = append(owners, todo.UserID)
+		resolvers = append(resolvers, &todoResolver{req: req, todo: todo})
+		req.todos.store(todo.ID, todo)
+	}
+
+	req.tasks.prime(ids...)
+	req.users.prime(owners...)
+
+	return resolvers
+}
+
+func taskResolvers(req *graphQLRequest, tasks []model.Task) []*taskResolver {
+	resolvers := make([]*taskResolver, 0, len(tasks))
+
+	for _, task := range tasks {
+		resolvers = append(resolvers, &taskResolver{req: req, task: task})
+		req.todos.prime(task.ToDoID)
+	}
+
+	return resolvers
+}
+
+//graphQLRoot resolves Query and Mutation ...
+type graphQLRoot struct{}
+
+//Me ...
+func (g *graphQLRoot) Me(ctx context.Context) *userResolver {
+	req := requestFrom(ctx)
+
+	return &userResolver{req: req, user: req.user}
+}
+
+//Users ...
+func (g *graphQLRoot) Users(ctx context.Context) ([]*userResolver, error) {
+	req := requestFrom(ctx)
+
+	if !req.user.IsAdmin() {
+		return nil, errGraphQLAdmin
+	}
+	if err := req.authorize("/users", "read"); err != nil {
+		return nil, err
+	}
+
+	users, err := model.ListUsers(ctx, req.user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	resolvers := make([]*userResolver, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+		resolvers = append(resolvers, &userResolver{req: req, user: user})
+	}
+	req.userTodos.prime(ids...)
+
+	return resolvers, nil
+}
+
+//User ...
+func (g *graphQLRoot) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	req := requestFrom(ctx)
+
+	userID, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != req.user.ID && !req.user.IsAdmin() {
+		return nil, errGraphQLForbidden
+	}
+	if err := req.authorize("/user/"+string(args.ID), "read"); err != nil {
+		return nil, err
+	}
+
+	user, err := req.user.GetUser(ctx, string(args.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &userResolver{req: req, user: user}, nil
+}
+
+//Todos ...
+func (g *graphQLRoot) Todos(ctx context.Context) ([]*todoResolver, error) {
+	req := requestFrom(ctx)
+
+	if err := req.authorize("/todos", "read"); err != nil {
+		return nil, err
+	}
+
+	owner := req.user.ID
+	if req.user.IsAdmin() {
+		owner = 0
+	}
+
+	todos, err := model.ListAllToDos(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return todoResolvers(req, todos), nil
+}
+
+//Todo ...
+func (g *graphQLRoot) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	req := requestFrom(ctx)
+
+	todoID, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.authorize("/task/"+string(args.ID), "read"); err != nil {
+		return nil, err
+	}
+
+	todo, err := model.CheckToDoAccess(ctx, req.user, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	return todoResolvers(req, []model.ToDo{todo})[0], nil
+}
+
+//Task ...
+func (g *graphQLRoot) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	req := requestFrom(ctx)
+
+	taskID, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, todo, err := model.CheckTaskAccess(ctx, req.user, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.authorize("/task/"+strconv.Itoa(todo.ID), "read"); err != nil {
+		return nil, err
+	}
+
+	return &taskResolver{req: req, task: task}, nil
+}
+
+//CreateToDo ...
+func (g *graphQLRoot) CreateToDo(ctx context.Context, args struct {
+	Name        string
+	Description *string
+}) (*todoResolver, error) {
+	req := requestFrom(ctx)
+
+	if err := req.authorize("/todo", "write"); err != nil {
+		return nil, err
+	}
+
+	todo := model.ToDo{Name: args.Name}
+	if args.Description != nil {
+		todo.Description = *args.Description
+	}
+
+	err := todo.CreateToDo(ctx, req.user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	model.PublishToDoEvent("todo.created", todo, todo)
+
+	return &todoResolver{req: req, todo: todo}, nil
+}
+
+//UpdateToDo ...
+func (g *graphQLRoot) UpdateToDo(ctx context.Context, args struct {
+	ID          graphql.ID
+	Name        *string
+	Description *string
+}) (*todoResolver, error) {
+	req := requestFrom(ctx)
+
+	todoID, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := model.CheckToDoAccess(ctx, req.user, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Name != nil {
+		if err := req.authorize("/todo/name/"+string(args.ID), "write"); err != nil {
+			return nil, err
+		}
+	}
+	if args.Description != nil {
+		if err := req.authorize("/todo/description/"+string(args.ID), "write"); err != nil {
+			return nil, err
+		}
+	}
+
+	if args.Name != nil {
+		err = (&model.ToDo{Name: *args.Name}).UpdateToDoName(ctx, todoID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if args.Description != nil {
+		err = (&model.ToDo{Description: *args.Description}).UpdateToDoDescription(ctx, todoID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	todo, err = model.GetAnyToDo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	model.PublishToDoEvent("todo.updated", todo, todo)
+
+	return &todoResolver{req: req, todo: todo}, nil
+}
+
+//DeleteToDo ...
+func (g *graphQLRoot) DeleteToDo(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	req := requestFrom(ctx)
+
+	todoID, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := req.authorize("/todo/"+string(args.ID), "write"); err != nil {
+		return false, err
+	}
+
+	todo, err := model.CheckToDoAccess(ctx, req.user, todoID)
+	if err != nil {
+		return false, err
+	}
+
+	owner := req.user.ID
+	if req.user.IsAdmin() {
+		owner = 0
+	}
+
+	err = todo.DeleteToDo(ctx, owner, todoID)
+	if err != nil {
+		return false, err
+	}
+
+	model.PublishToDoEvent("todo.deleted", todo, todo)
+
+	return true, nil
+}
+
+//taskInput ...
+type taskInput struct {
+	Name        *string
+	DateCreated *string
+	DateFinish  *string
+	Priority    *int32
+	Status      *bool
+}
+
+//CreateTask ...
+func (g *graphQLRoot) CreateTask(ctx context.Context, args struct {
+	TodoID graphql.ID
+	Input  taskInput
+}) (*taskResolver, error) {
+	req := requestFrom(ctx)
+
+	todoID, err := parseID(args.TodoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.authorize("/task/"+string(args.TodoID), "write"); err != nil {
+		return nil, err
+	}
+
+	if _, err := model.CheckToDoAccess(ctx, req.user, todoID); err != nil {
+		return nil, err
+	}
+
+	task := model.Task{Priority: "0"}
+	if args.Input.Name != nil {
+		task.Name = *args.Input.Name
+	}
+	if args.Input.DateCreated != nil {
+		task.DateCreated = *args.Input.DateCreated
+	}
+	if args.Input.DateFinish != nil {
+		task.DateFinish = *args.Input.DateFinish
+	}
+	if args.Input.Priority != nil {
+		task.Priority = strconv.Itoa(int(*args.Input.Priority))
+	}
+	if args.Input.Status != nil {
+		task.Status = *args.Input.Status
+	}
+
+	err = task.CreateTask(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	model.PublishTaskEvent(ctx, "task.created", todoID, task.ID)
+
+	return &taskResolver{req: req, task: task}, nil
+}
+
+//UpdateTask applies every given field, each one guarded like its REST route ...
+func (g *graphQLRoot) UpdateTask(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input taskInput
+}) (*taskResolver, error) {
+	req := requestFrom(ctx)
+
+	taskID, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, todo, err := model.CheckTaskAccess(ctx, req.user, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	todoPath := strconv.Itoa(todo.ID)
+	input := args.Input
+
+	if input.Name != nil {
+		if err := req.authorize("/task/name/"+todoPath, "write"); err != nil {
+			return nil, err
+		}
+	}
+	if input.DateCreated != nil || input.DateFinish != nil {
+		if err := req.authorize("/task/date/"+todoPath, "write"); err != nil {
+			return nil, err
+		}
+	}
+	if input.Priority != nil {
+		if err := req.authorize("/task/priority/"+todoPath, "write"); err != nil {
+			return nil, err
+		}
+	}
+	if input.Status != nil {
+		if err := req.authorize("/task/status/"+todoPath, "write"); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Name != nil {
+		task.Name = *input.Name
+		if err = task.UpdateTaskName(ctx, todo.ID, task.ID); err != nil {
+			return nil, err
+		}
+	}
+	if input.DateCreated != nil {
+		task.DateCreated = *input.DateCreated
+		if err = task.UpdateTaskDateStart(ctx, todo.ID, task.ID); err != nil {
+			return nil, err
+		}
+	}
+	if input.DateFinish != nil {
+		task.DateFinish = *input.DateFinish
+		if err = task.UpdateTaskDateFinish(ctx, todo.ID, task.ID); err != nil {
+			return nil, err
+		}
+	}
+	if input.Priority != nil {
+		task.Priority = strconv.Itoa(int(*input.Priority))
+		if err = task.UpdateTaskPriority(ctx, todo.ID, task.ID); err != nil {
+			return nil, err
+		}
+	}
+	if input.Status != nil {
+		task.Status = *input.Status
+		if err = task.UpdateTaskStatus(ctx, todo.ID, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	model.PublishTaskEvent(ctx, "task.updated", todo.ID, task.ID)
+
+	//the row holds what the updates derived, like dateDone when the status changed
+	task, err = model.GetAnyTask(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskResolver{req: req, task: task}, nil
+}
+
+//DeleteTask ...
+func (g *graphQLRoot) DeleteTask(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	req := requestFrom(ctx)
+
+	taskID, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := req.authorize("/task/"+string(args.ID), "write"); err != nil {
+		return false, err
+	}
+
+	task, todo, err := model.CheckTaskAccess(ctx, req.user, taskID)
+	if err != nil {
+		return false, err
+	}
+
+	err = task.DeleteTask(ctx, todo.ID, task.ID)
+	if err != nil {
+		return false, err
+	}
+
+	model.PublishToDoEvent("task.deleted", todo, task)
+
+	return true, nil
+}
+
+//UpdatePassword ...
+func (g *graphQLRoot) UpdatePassword(ctx context.Context, args struct {
+	Oldpass string
+	Newpass string
+}) (bool, error) {
+	req := requestFrom(ctx)
+
+	if err := req.authorize("/user/password2", "write"); err != nil {
+		return false, err
+	}
+
+	if len(args.Newpass) <= 4 {
+		return false, errors.New("Password must be longer than 4 characters")
+	}
+
+	err := req.user.UpdatePassword(ctx, args.Oldpass, args.Newpass)
+	if err != nil {
+		return false, errors.New("Error updating password")
+	}
+
+	return true, nil
+}
+
+//UpdateUserType ...
+func (g *graphQLRoot) UpdateUserType(ctx context.Context, args struct {
+	ID   graphql.ID
+	Type string
+}) (bool, error) {
+	req := requestFrom(ctx)
+
+	userID, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if !req.user.IsAdmin() || args.Type == "" {
+		return false, errGraphQLAdmin
+	}
+	if err := req.authorize("/user/type", "write"); err != nil {
+		return false, err
+	}
+
+	user := model.User{ID: userID, Type: args.Type}
+
+	err = user.UpdateType(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+//DeleteUser ...
+func (g *graphQLRoot) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	req := requestFrom(ctx)
+
+	if _, err := parseID(args.ID); err != nil {
+		return false, err
+	}
+
+	if !req.user.IsAdmin() {
+		return false, errGraphQLAdmin
+	}
+	if err := req.authorize("/user/"+string(args.ID), "write"); err != nil {
+		return false, err
+	}
+
+	err := req.user.DeleteUser(ctx, string(args.ID))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+//userResolver ...
+type userResolver struct {
+	req  *graphQLRequest
+	user model.User
+}
+
+//ID ...
+func (u *userResolver) ID() graphql.ID { return graphQLID(u.user.ID) }
+
+//Type ...
+func (u *userResolver) Type() string { return u.user.Type }
+
+//Username ...
+func (u *userResolver) Username() string { return u.user.Username }
+
+//Firstname ...
+func (u *userResolver) Firstname() string { return u.user.FirstName }
+
+//Lastname ...
+func (u *userResolver) Lastname() string { return u.user.LastName }
+
+//Email ...
+func (u *userResolver) Email() string { return u.user.Email }
+
+//Todos ...
+func (u *userResolver) Todos(ctx context.Context) ([]*todoResolver, error) {
+	if u.user.ID != u.req.user.ID && !u.req.user.IsAdmin() {
+		return nil, errGraphQLForbidden
+	}
+	if err := u.req.authorize("/todos", "read"); err != nil {
+		return nil, err
+	}
+
+	todos, err := u.req.userTodos.load(ctx, u.user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return todoResolvers(u.req, todos.([]model.ToDo)), nil
+}
+
+//todoResolver ...
+type todoResolver struct {
+	req  *graphQLRequest
+	todo model.ToDo
+}
+
+//ID ...
+func (t *todoResolver) ID() graphql.ID { return graphQLID(t.todo.ID) }
+
+//Name ...
+func (t *todoResolver) Name() string { return t.todo.Name }
+
+//Description ...
+func (t *todoResolver) Description() string { return t.todo.Description }
+
+//Owner ...
+func (t *todoResolver) Owner(ctx context.Context) (*userResolver, error) {
+	user, err := t.req.users.load(ctx, t.todo.UserID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	return &userResolver{req: t.req, user: user.(model.User)}, nil
+}
+
+//Tasks ...
+func (t *todoResolver) Tasks(ctx context.Context, args struct{ Completed *bool }) ([]*taskResolver, error) {
+	if err := t.req.authorize("/task/"+strconv.Itoa(t.todo.ID), "read"); err != nil {
+		return nil, err
+	}
+
+	tasks, err := t.req.tasks.load(ctx, t.todo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []model.Task
+	for _, task := range tasks.([]model.Task) {
+		if args.Completed == nil || task.Status == *args.Completed {
+			filtered = append(filtered, task)
+		}
+	}
+
+	return taskResolvers(t.req, filtered), nil
+}
+
+//taskResolver ...
+type taskResolver struct {
+	req  *graphQLRequest
+	task model.Task
+}
+
+//ID ...
+func (t *taskResolver) ID() graphql.ID { return graphQLID(t.task.ID) }
+
+//Name ...
+func (t *taskResolver) Name() string { return t.task.Name }
+
+//DateCreated ...
+func (t *taskResolver) DateCreated() string { return t.task.DateCreated }
+
+//DateFinish ...
+func (t *taskResolver) DateFinish() string { return t.task.DateFinish }
+
+//DateDone ...
+func (t *taskResolver) DateDone() string { return t.task.DateDone }
+
+//Priority ...
+func (t *taskResolver) Priority() *int32 {
+	priority, err := strconv.Atoi(t.task.Priority)
+	if err != nil {
+		return nil
+	}
+
+	p := int32(priority)
+	return &p
+}
+
+//Status ...
+func (t *taskResolver) Status() bool { return t.task.Status }
+
+//Todo ...
+func (t *taskResolver) Todo(ctx context.Context) (*todoResolver, error) {
+	todo, err := t.req.todos.load(ctx, t.task.ToDoID)
+	if err != nil || todo == nil {
+		return nil, err
+	}
+
+	return &todoResolver{req: t.req, todo: todo.(model.ToDo)}, nil
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//readyTimeout bounds the readiness checks together
+const readyTimeout = 2 * time.Second
+
+//HealthController serves the liveness and readiness probes and the admin diagnostics ...
+type HealthController struct {
+	Version    string
+	Config     utils.Config
+	RBACLoaded func() bool
+	started    time.Time
+}
+
+//NewHealthController ...
+func NewHealthController(version string, conf utils.Config, rbacLoaded func() bool) *HealthController {
+	return &HealthController{Version: version, Config: conf.Redacted(), RBACLoaded: rbacLoaded, started: time.Now()}
+}
+
+//Healthz only tells that the process serves requests ...
+func (hc *HealthController) Healthz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	utils.WriteJSON(w, map[string]string{"status": "ok"}, 200)
+}
+
+//Readyz checks the DB, the RBAC rules and the schema version, 503 lists the failing checks ...
+func (hc *HealthController) Readyz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	checks := map[string]string{
+		"database":   "ok",
+		"rbac":       "ok",
+		"migrations": "ok",
+	}
+	status := 200
+
+	//the probe is public, the causes only go to the log
+	fail := func(check, state string, err error) {
+		checks[check] = state
+		status = http.StatusServiceUnavailable
+		if err != nil {
+			utils.RequestLogger(r).WithError(err).WithField("check", check).Warn("readiness check failed")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(utils.RequestContext(r), readyTimeout)
+	defer cancel()
+
+	if db := utils.SQLAcc.GetSQLDB(); db == nil {
+		fail("database", "not connected", nil)
+	} else if err := db.PingContext(ctx); err != nil {
+		fail("database", "unreachable", err)
+	}
+
+	if hc.RBACLoaded != nil && !hc.RBACLoaded() {
+		fail("rbac", "not loaded", nil)
+	}
+
+	if checks["database"] != "ok" {
+		fail("migrations", "unknown", nil)
+	} else if current, latest, err := model.SchemaVersion(ctx); err != nil {
+		fail("migrations", "unknown", err)
+	} else if current != latest {
+		fail("migrations", "outdated", fmt.Errorf("schema version %d, expected %d", current, latest))
+	}
+
+	state := "ok"
+	if status != 200 {
+		state = "unavailable"
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{"status": state, "checks": checks}, status)
+}
+
+//DebugInfo shows the build, the configuration without secrets and the uptime to admins ...
+func (hc *HealthController) DebugInfo(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	if !user.IsAdmin() {
+		utils.WriteJSON(w, "Acces Forbidden", http.StatusForbidden)
+		return
+	}
+
+	build := map[string]string{"version": hc.Version, "go": runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.time" {
+				build[setting.Key] = setting.Value
+			}
+		}
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"build":   build,
+		"config":  hc.Config,
+		"started": hc.started.UTC().Format(time.RFC3339),
+		"uptime":  time.Since(hc.started).Round(time.Second).String(),
+	}, 200)
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+)
+
+//KeysController publishes the keys verifying our tokens, for other services ...
+type KeysController struct {
+	keys *utils.KeySet
+}
+
+//NewKeysController ...
+func NewKeysController(keys *utils.KeySet) *KeysController {
+	return &KeysController{keys: keys}
+}
+
+//JWKS serves /.well-known/jwks.json, clients refetch it when they meet an unknown kid ...
+func (kc *KeysController) JWKS(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	utils.WriteJSON(w, kc.keys.JWKS(), 200)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+)
+
+//ExportMarkdown renders a list as a Markdown checklist ...
+func (tdc ToDoController) ExportMarkdown(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	todoID, err := strconv.Atoi(params.ByName("id"))
+
+	todo, err := model.GetAnyToDo(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 400)
+		return
+	}
+
+	tasks, err := model.ListTasks(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte(model.FormatMarkdown(todo, tasks)))
+}
+
+//ImportMarkdown creates tasks from the `- [ ]` items of a Markdown body ...
+func (tdc ToDoController) ImportMarkdown(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	todoID, err := strconv.Atoi(params.ByName("id"))
+
+	todo, err := model.GetAnyToDo(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 400)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxTextImportSize))
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), 400)
+		return
+	}
+
+	tasks, lineErrors := model.ParseMarkdown(string(body))
+
+	created, err := createTasks(utils.RequestContext(r), todo, tasks)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("importing markdown")
+		utils.WriteJSON(w, map[string]interface{}{"created": created, "errors": lineErrors}, 500)
+		return
+	}
+
+	status := 200
+	if len(created) == 0 {
+		status = 400
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{"created": created, "errors": lineErrors}, status)
+}
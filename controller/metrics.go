@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//MetricsController serves /metrics to the scrapers allowed by utils.MetricsConf ...
+type MetricsController struct {
+	networks []*net.IPNet
+	token    string
+	handler  http.Handler
+}
+
+//NewMetricsController ...
+func NewMetricsController(conf utils.MetricsConf) (*MetricsController, error) {
+	networks, err := utils.ParseNetworks(conf.AllowedNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetricsController{networks: networks, token: conf.Token, handler: promhttp.Handler()}, nil
+}
+
+//Serve ...
+func (mc *MetricsController) Serve(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !mc.allowed(r) {
+		utils.WriteJSON(w, "Acces Forbidden", http.StatusForbidden)
+		return
+	}
+
+	mc.handler.ServeHTTP(w, r)
+}
+
+//allowed accepts the configured bearer token or a client from an allowed network ...
+func (mc *MetricsController) allowed(r *http.Request) bool {
+	if mc.token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(mc.token)) == 1 {
+			return true
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range mc.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//MySessions lists where the caller is logged in ...
+func (uc Users) MySessions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	sessions, err := model.ListSessions(utils.RequestContext(r), user.ID)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("listing sessions")
+		utils.WriteJSON(w, "Error listing sessions", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == user.SessionID
+	}
+
+	utils.WriteJSON(w, sessions, http.StatusOK)
+}
+
+//RevokeMySession logs one device of the caller out ...
+func (uc Users) RevokeMySession(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	revokeSession(w, r, user.ID, params.ByName("id"))
+}
+
+//RevokeOtherSessions logs the caller out everywhere but the current session ...
+func (uc Users) RevokeOtherSessions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	revokeAllSessions(w, r, user.ID, user.SessionID)
+}
+
+//UserSessions lists the sessions of any user, admin only ...
+func (uc Users) UserSessions(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userID, ok := adminTarget(w, r, params)
+	if !ok {
+		return
+	}
+
+	sessions, err := model.ListSessions(utils.RequestContext(r), userID)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("listing sessions")
+		utils.WriteJSON(w, "Error listing sessions", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, sessions, http.StatusOK)
+}
+
+//RevokeUserSession ends one session of any user, admin only ...
+func (uc Users) RevokeUserSession(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userID, ok := adminTarget(w, r, params)
+	if !ok {
+		return
+	}
+
+	revokeSession(w, r, userID, params.ByName("sid"))
+}
+
+//RevokeUserSessions ends every session of any user, admin only ...
+func (uc Users) RevokeUserSessions(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	userID, ok := adminTarget(w, r, params)
+	if !ok {
+		return
+	}
+
+	revokeAllSessions(w, r, userID, "")
+}
+
+//adminTarget returns the user of the :id parameter once the caller is known to be an admin ...
+func adminTarget(w http.ResponseWriter, r *http.Request, params httprouter.Params) (int, bool) {
+	user := context.Get(r, "user").(model.User)
+
+	if !user.IsAdmin() {
+		utils.WriteJSON(w, "You are not allowed to manage sessions of other users", http.StatusForbidden)
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(params.ByName("id"))
+	if err != nil {
+		utils.WriteJSON(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func revokeSession(w http.ResponseWriter, r *http.Request, userID int, id string) {
+	err := model.RevokeSession(utils.RequestContext(r), userID, id)
+
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("revoking session")
+		utils.WriteJSON(w, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, "Session revoked", http.StatusOK)
+}
+
+func revokeAllSessions(w http.ResponseWriter, r *http.Request, userID int, keep string) {
+	revoked, err := model.RevokeOtherSessions(utils.RequestContext(r), userID, keep)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("revoking sessions")
+		utils.WriteJSON(w, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]int64{"revoked": revoked}, http.StatusOK)
+}
@@ -0,0 +1,385 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	middlleware "github.com/bicom/todos/middleware"
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	socketWriteWait  = 10 * time.Second
+	socketPongWait   = 60 * time.Second
+	socketPingPeriod = socketPongWait * 9 / 10
+	socketMaxMessage = 64 * 1024
+	//socketRecheck is how often a quiet socket checks that its session or API token wasn't revoked
+	socketRecheck = time.Minute
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	//the token can sit in the URL, so other sites are held to the CORS allowlist too
+	CheckOrigin: middlleware.OriginAllowed,
+}
+
+var errUnknownOp = errors.New("Unknown operation")
+
+//SocketController ...
+type SocketController struct{}
+
+//SocketCommand is a message sent by the client, task operations use task.id ...
+type SocketCommand struct {
+	ID     string     `json:"id"` //echoed back in the reply
+	Op     string     `json:"op"`
+	ToDoID int        `json:"todoID"`
+	ToDo   model.ToDo `json:"todo"`
+	Task   model.Task `json:"task"`
+}
+
+//SocketReply is an acknowledgement, an error or a pushed event ...
+type SocketReply struct {
+	ID     string       `json:"id,omitempty"`
+	Type   string       `json:"type"` //ack, error or event
+	Status int          `json:"status,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Data   interface{}  `json:"data,omitempty"`
+	Event  *utils.Event `json:"event,omitempty"`
+}
+
+type socketSession struct {
+	conn *websocket.Conn
+	user model.User
+	ctx  context.Context //parent of the command spans
+
+	writeMu sync.Mutex
+
+	todosMu sync.RWMutex
+	todos   map[int]bool //subscribed lists
+}
+
+//Connect upgrades the request and serves the command protocol ...
+func (sc SocketController) Connect(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		//upgrader already replied with an error
+		return
+	}
+	defer conn.Close()
+
+	s := &socketSession{conn: conn, user: user, ctx: utils.RequestContext(r), todos: make(map[int]bool)}
+
+	sub := utils.Events.Subscribe(s.wants)
+	defer utils.Events.Unsubscribe(sub)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go s.push(sub, done)
+
+	s.read()
+}
+
+func (s *socketSession) wants(e utils.Event) bool {
+	s.todosMu.RLock()
+	defer s.todosMu.RUnlock()
+
+	return s.todos[e.ToDoID] && canSeeEvent(s.user, e)
+}
+
+func (s *socketSession) write(reply SocketReply) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+
+	return s.conn.WriteJSON(reply)
+}
+
+//credentialEnded tells errors of model.CheckCredential that end the socket from failed lookups
+func credentialEnded(err error) bool {
+	return err == model.ErrSessionEnded || err == model.ErrAPITokenInvalid
+}
+
+//end closes the socket of a logged out or revoked client ...
+func (s *socketSession) end() {
+	message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session ended")
+
+	s.writeMu.Lock()
+	s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(socketWriteWait))
+	s.writeMu.Unlock()
+	s.conn.Close()
+}
+
+func (s *socketSession) push(sub *utils.Subscription, done chan struct{}) {
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+
+	recheck := time.NewTicker(socketRecheck)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				//dropped by the hub, let the client reconnect and subscribe again
+				message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow")
+				if utils.Events.Closed() {
+					message = websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down")
+				}
+
+				s.writeMu.Lock()
+				s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(socketWriteWait))
+				s.writeMu.Unlock()
+				s.conn.Close()
+				return
+			}
+			if s.write(SocketReply{Type: "event", Event: &e}) != nil {
+				s.conn.Close()
+				return
+			}
+		case <-recheck.C:
+			err := model.CheckCredential(s.ctx, s.user, time.Now())
+			if credentialEnded(err) {
+				s.end()
+				return
+			}
+			if err != nil {
+				utils.Logger.WithError(err).Error("socket credential check")
+			}
+		case <-ping.C:
+			s.writeMu.Lock()
+			err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait))
+			s.writeMu.Unlock()
+
+			if err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *socketSession) read() {
+	s.conn.SetReadLimit(socketMaxMessage)
+	s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	for {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var cmd SocketCommand
+
+		err = json.Unmarshal(message, &cmd)
+		if err != nil {
+			s.write(SocketReply{Type: "error", Status: 400, Error: err.Error()})
+			continue
+		}
+
+		//a logout, revoked session or deleted API token stops the commands right away
+		err = model.CheckCredential(s.ctx, s.user, time.Now())
+		if credentialEnded(err) {
+			s.end()
+			return
+		}
+		if err != nil {
+			s.write(SocketReply{ID: cmd.ID, Type: "error", Status: 500, Error: err.Error()})
+			continue
+		}
+
+		ctx, span := utils.Tracer().Start(s.ctx, "socket "+cmd.Op)
+		data, status, err := s.handle(ctx, cmd)
+		span.End()
+
+		if err != nil {
+			s.write(SocketReply{ID: cmd.ID, Type: "error", Status: status, Error: err.Error()})
+			continue
+		}
+
+		s.write(SocketReply{ID: cmd.ID, Type: "ack", Status: status, Data: data})
+	}
+}
+
+//handle runs a command with the same rules CheckTodo and CheckTask apply on REST routes ...
+func (s *socketSession) handle(ctx context.Context, cmd SocketCommand) (interface{}, int, error) {
+	switch cmd.Op {
+	case "subscribe":
+		todo, err := model.CheckToDoAccess(ctx, s.user, cmd.ToDoID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		s.todosMu.Lock()
+		s.todos[todo.ID] = true
+		s.todosMu.Unlock()
+
+		tasks, err := model.ListTasks(ctx, todo.ID)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		return tasks, 200, nil
+
+	case "unsubscribe":
+		s.todosMu.Lock()
+		delete(s.todos, cmd.ToDoID)
+		s.todosMu.Unlock()
+
+		return nil, 200, nil
+
+	case "listTasks":
+		todo, err := model.CheckToDoAccess(ctx, s.user, cmd.ToDoID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		tasks, err := model.ListTasks(ctx, todo.ID)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		return tasks, 200, nil
+
+	case "createTask":
+		todo, err := model.CheckToDoAccess(ctx, s.user, cmd.ToDoID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		task := cmd.Task
+
+		err = task.CreateTask(ctx, todo.ID)
+		if err != nil {
+			return nil, 400, err
+		}
+
+		model.PublishTaskEvent(ctx, "task.created", todo.ID, task.ID)
+
+		return task, 200, nil
+
+	case "updateTaskName", "updateTaskDateFinish", "updateTaskPriority", "updateTaskStatus":
+		current, todo, err := model.CheckTaskAccess(ctx, s.user, cmd.Task.ID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		task := cmd.Task
+
+		switch cmd.Op {
+		case "updateTaskName":
+			err = task.UpdateTaskName(ctx, todo.ID, current.ID)
+		case "updateTaskDateFinish":
+			err = task.UpdateTaskDateFinish(ctx, todo.ID, current.ID)
+		case "updateTaskPriority":
+			err = task.UpdateTaskPriority(ctx, todo.ID, current.ID)
+		case "updateTaskStatus":
+			err = task.UpdateTaskStatus(ctx, todo.ID, current.ID)
+		}
+
+		if err != nil {
+			return nil, 500, err
+		}
+
+		model.PublishTaskEvent(ctx, "task.updated", todo.ID, current.ID)
+
+		current, err = model.GetAnyTask(ctx, current.ID)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		return current, 200, nil
+
+	case "deleteTask":
+		task, todo, err := model.CheckTaskAccess(ctx, s.user, cmd.Task.ID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		err = task.DeleteTask(ctx, todo.ID, task.ID)
+		if err != nil {
+			return nil, 400, err
+		}
+
+		model.PublishToDoEvent("task.deleted", todo, task)
+
+		return task, 200, nil
+
+	case "updateToDoName", "updateToDoDescription":
+		todo, err := model.CheckToDoAccess(ctx, s.user, cmd.ToDoID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		if cmd.Op == "updateToDoName" {
+			err = cmd.ToDo.UpdateToDoName(ctx, todo.ID)
+		} else {
+			err = cmd.ToDo.UpdateToDoDescription(ctx, todo.ID)
+		}
+
+		if err != nil {
+			return nil, 500, err
+		}
+
+		todo, err = model.GetAnyToDo(ctx, todo.ID)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		model.PublishToDoEvent("todo.updated", todo, todo)
+
+		return todo, 200, nil
+
+	case "deleteToDo":
+		todo, err := model.CheckToDoAccess(ctx, s.user, cmd.ToDoID)
+		if err != nil {
+			return nil, accessStatus(err), err
+		}
+
+		owner := s.user.ID
+		if s.user.IsAdmin() {
+			owner = 0
+		}
+
+		err = todo.DeleteToDo(ctx, owner, todo.ID)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		model.PublishToDoEvent("todo.deleted", todo, todo)
+
+		s.todosMu.Lock()
+		delete(s.todos, todo.ID)
+		s.todosMu.Unlock()
+
+		return todo, 200, nil
+	}
+
+	return nil, 400, errUnknownOp
+}
+
+//accessStatus mirrors the codes CheckTodo and CheckTask reply with ...
+func accessStatus(err error) int {
+	if err == model.ErrListForbidden {
+		return 403
+	}
+
+	return 400
+}
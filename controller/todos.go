@@ -2,7 +2,6 @@ package controller
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -25,18 +24,20 @@ func (tdc ToDoController) CreateToDo(w http.ResponseWriter, r *http.Request, par
 	err := json.NewDecoder(r.Body).Decode(&todo)
 
 	if err != nil {
-		fmt.Println(err)
+		utils.RequestLogger(r).WithError(err).Error("decoding list")
 		utils.WriteJSON(w, err.Error(), 400)
 		return
 	}
 
-	err = todo.CreateToDo(user.ID)
+	err = todo.CreateToDo(utils.RequestContext(r), user.ID)
 	if err != nil {
-		fmt.Println(err)
+		utils.RequestLogger(r).WithError(err).Error("creating list")
 		utils.WriteJSON(w, err.Error(), 400)
 		return
 	}
 
+	model.PublishToDoEvent("todo.created", todo, todo)
+
 	utils.WriteJSON(w, todo, 200)
 }
 
@@ -49,13 +50,15 @@ func (tdc ToDoController) CreateTask(w http.ResponseWriter, r *http.Request, par
 
 	err = json.NewDecoder(r.Body).Decode(&task)
 
-	err = task.CreateTask(todoID)
+	err = task.CreateTask(utils.RequestContext(r), todoID)
 	if err != nil {
-		fmt.Println(err)
+		utils.RequestLogger(r).WithError(err).Error("creating task")
 		utils.WriteJSON(w, err.Error(), 400)
 		return
 	}
 
+	model.PublishTaskEvent(utils.RequestContext(r), "task.created", todoID, task.ID)
+
 	utils.WriteJSON(w, task, 200)
 }
 
@@ -65,25 +68,27 @@ func (tdc *ToDoController) DeleteToDo(w http.ResponseWriter, r *http.Request, pa
 
 	todoID, err := strconv.Atoi(params.ByName("id"))
 
-	var todo model.ToDo
+	todo, err := model.GetAnyToDo(utils.RequestContext(r), todoID)
 
 	if user.IsAdmin() {
-		err = todo.DeleteToDo(0, todoID)
+		err = todo.DeleteToDo(utils.RequestContext(r), 0, todoID)
 
 		if err != nil {
 			utils.WriteJSON(w, err, 500)
 			return
 		}
 	} else {
-		err = todo.DeleteToDo(user.ID, todoID)
+		err = todo.DeleteToDo(utils.RequestContext(r), user.ID, todoID)
 
 		if err != nil {
-			fmt.Println(err)
+			utils.RequestLogger(r).WithError(err).Error("deleting list")
 			utils.WriteJSON(w, err.Error(), 403)
 			return
 		}
 	}
 
+	model.PublishToDoEvent("todo.deleted", todo, todo)
+
 	utils.WriteJSON(w, "ToDo table deleted.", 200)
 }
 
@@ -94,16 +99,20 @@ func (tdc *ToDoController) DeleteTask(w http.ResponseWriter, r *http.Request, pa
 
 	var task model.Task
 
-	task, err = model.GetAnyTask(taskID)
+	task, err = model.GetAnyTask(utils.RequestContext(r), taskID)
 
-	err = task.DeleteTask(task.ToDoID, task.ID)
+	err = task.DeleteTask(utils.RequestContext(r), task.ToDoID, task.ID)
 
 	if err != nil {
-		fmt.Println(err)
+		utils.RequestLogger(r).WithError(err).Error("deleting task")
 		utils.WriteJSON(w, err, 400)
 		return
 	}
 
+	if todo, err := model.GetAnyToDo(utils.RequestContext(r), task.ToDoID); err == nil {
+		model.PublishToDoEvent("task.deleted", todo, task)
+	}
+
 	utils.WriteJSON(w, "Task deleted", 200)
 }
 
@@ -121,13 +130,17 @@ func (tdc ToDoController) UpdateToDoName(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	err = todo.UpdateToDoName(todoID)
+	err = todo.UpdateToDoName(utils.RequestContext(r), todoID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 403)
 		return
 	}
 
+	if todo, err = model.GetAnyToDo(utils.RequestContext(r), todoID); err == nil {
+		model.PublishToDoEvent("todo.updated", todo, todo)
+	}
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -145,13 +158,17 @@ func (tdc ToDoController) UpdateToDoDescription(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = todo.UpdateToDoDescription(todoID)
+	err = todo.UpdateToDoDescription(utils.RequestContext(r), todoID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 403)
 		return
 	}
 
+	if todo, err = model.GetAnyToDo(utils.RequestContext(r), todoID); err == nil {
+		model.PublishToDoEvent("todo.updated", todo, todo)
+	}
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -164,13 +181,15 @@ func (tdc ToDoController) UpdateTaskName(w http.ResponseWriter, r *http.Request,
 
 	err = json.NewDecoder(r.Body).Decode(&task) //send ID and name
 
-	err = task.UpdateTaskName(todoID, task.ID)
+	err = task.UpdateTaskName(utils.RequestContext(r), todoID, task.ID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
 		return
 	}
 
+	model.PublishTaskEvent(utils.RequestContext(r), "task.updated", todoID, task.ID)
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -181,13 +200,15 @@ func (tdc ToDoController) UpdateTaskDateStart(w http.ResponseWriter, r *http.Req
 
 	err := json.NewDecoder(r.Body).Decode(&task) //send ID, ToDoID and name
 
-	err = task.UpdateTaskDateStart(task.ToDoID, task.ID)
+	err = task.UpdateTaskDateStart(utils.RequestContext(r), task.ToDoID, task.ID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
 		return
 	}
 
+	model.PublishTaskEvent(utils.RequestContext(r), "task.updated", task.ToDoID, task.ID)
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -200,13 +221,15 @@ func (tdc ToDoController) UpdateTaskDateFinish(w http.ResponseWriter, r *http.Re
 
 	err = json.NewDecoder(r.Body).Decode(&task) //send ID, ToDoID and name
 
-	err = task.UpdateTaskDateFinish(todoID, task.ID)
+	err = task.UpdateTaskDateFinish(utils.RequestContext(r), todoID, task.ID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
 		return
 	}
 
+	model.PublishTaskEvent(utils.RequestContext(r), "task.updated", todoID, task.ID)
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -217,13 +240,15 @@ func (tdc ToDoController) UpdateTaskPriority(w http.ResponseWriter, r *http.Requ
 
 	err := json.NewDecoder(r.Body).Decode(&task) //send ID, ToDoID and name
 
-	err = task.UpdateTaskPriority(task.ToDoID, task.ID)
+	err = task.UpdateTaskPriority(utils.RequestContext(r), task.ToDoID, task.ID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
 		return
 	}
 
+	model.PublishTaskEvent(utils.RequestContext(r), "task.updated", task.ToDoID, task.ID)
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -234,13 +259,15 @@ func (tdc ToDoController) UpdateTaskStatus(w http.ResponseWriter, r *http.Reques
 
 	err := json.NewDecoder(r.Body).Decode(&task) //send ID, ToDoID and name
 
-	err = task.UpdateTaskStatus(task.ToDoID, task.ID)
+	err = task.UpdateTaskStatus(utils.RequestContext(r), task.ToDoID, task.ID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
 		return
 	}
 
+	model.PublishTaskEvent(utils.RequestContext(r), "task.updated", task.ToDoID, task.ID)
+
 	utils.WriteJSON(w, "Update done!", 200)
 }
 
@@ -252,15 +279,15 @@ func (tdc ToDoController) ListAllToDos(w http.ResponseWriter, r *http.Request, _
 	var err error
 
 	if user.IsAdmin() {
-		todos, err = model.ListAllToDos(0)
+		todos, err = model.ListAllToDos(utils.RequestContext(r), 0)
 		if err != nil {
-			fmt.Println(err)
+			utils.RequestLogger(r).WithError(err).Error("listing lists")
 			utils.WriteJSON(w, "Unable to show all created ToDos", 400)
 		}
 	} else {
-		todos, err = model.ListAllToDos(user.ID)
+		todos, err = model.ListAllToDos(utils.RequestContext(r), user.ID)
 		if err != nil {
-			fmt.Println(err)
+			utils.RequestLogger(r).WithError(err).Error("listing lists")
 			utils.WriteJSON(w, "Unable to show all created ToDos", 400)
 		}
 	}
@@ -275,7 +302,7 @@ func (tdc ToDoController) ListTasks(w http.ResponseWriter, r *http.Request, para
 
 	var tasks []model.Task
 
-	tasks, err = model.ListTasks(todoID)
+	tasks, err = model.ListTasks(utils.RequestContext(r), todoID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
@@ -292,7 +319,7 @@ func (tdc ToDoController) ListAllActiveTasks(w http.ResponseWriter, r *http.Requ
 
 	var activeTasks []model.Task
 
-	activeTasks, err = model.ListAllActiveTasks(todoID)
+	activeTasks, err = model.ListAllActiveTasks(utils.RequestContext(r), todoID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
@@ -310,7 +337,7 @@ func (tdc ToDoController) ListCompletedTasks(w http.ResponseWriter, r *http.Requ
 
 	var activeTasks []model.Task
 
-	activeTasks, err = model.ListAllActiveTasks(todoID)
+	activeTasks, err = model.ListAllActiveTasks(utils.RequestContext(r), todoID)
 
 	if err != nil {
 		utils.WriteJSON(w, err, 500)
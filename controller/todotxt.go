@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+)
+
+//maxTextImportSize limits the body of the text import routes ...
+const maxTextImportSize = 1 << 20
+
+//ExportTodoTxt renders the tasks of a list as todo.txt ...
+func (tdc ToDoController) ExportTodoTxt(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	todoID, err := strconv.Atoi(params.ByName("id"))
+
+	todo, err := model.GetAnyToDo(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 400)
+		return
+	}
+
+	tasks, err := model.ListTasks(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=todo.txt")
+	w.WriteHeader(200)
+	w.Write([]byte(model.FormatTodoTxt(todo, tasks)))
+}
+
+//ImportTodoTxt creates tasks from a todo.txt body, invalid lines are reported and skipped ...
+func (tdc ToDoController) ImportTodoTxt(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+
+	todoID, err := strconv.Atoi(params.ByName("id"))
+
+	todo, err := model.GetAnyToDo(utils.RequestContext(r), todoID)
+	if err != nil {
+		utils.WriteJSON(w, err, 400)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxTextImportSize))
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), 400)
+		return
+	}
+
+	tasks, lineErrors := model.ParseTodoTxt(todo, string(body))
+
+	created, err := createTasks(utils.RequestContext(r), todo, tasks)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("importing todo.txt")
+		utils.WriteJSON(w, map[string]interface{}{"created": created, "errors": lineErrors}, 500)
+		return
+	}
+
+	status := 200
+	if len(created) == 0 && len(lineErrors) > 0 {
+		status = 400
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{"created": created, "errors": lineErrors}, status)
+}
+
+//createTasks adds the parsed tasks to the list in one transaction and publishes them ...
+func createTasks(ctx context.Context, todo model.ToDo, tasks []model.Task) ([]model.Task, error) {
+	created := []model.Task{}
+
+	err := model.CreateTasks(ctx, todo.ID, tasks)
+	if err != nil {
+		return created, err
+	}
+
+	for _, task := range tasks {
+		model.PublishTaskEvent(ctx, "task.created", todo.ID, task.ID)
+
+		created = append(created, task)
+	}
+
+	return created, nil
+}
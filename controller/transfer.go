@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//maxImportSize limits the body of POST /import ...
+const maxImportSize = 10 << 20
+
+var csvHeader = []string{"todo_id", "todo_name", "todo_description", "task_id", "task_name", "date_created", "date_finish", "priority", "status", "date_done"}
+
+//Export streams all lists of the caller with their tasks as JSON or CSV ...
+func (tdc ToDoController) Export(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "csv" {
+		utils.WriteJSON(w, "Format must be json or csv", 400)
+		return
+	}
+
+	writer := csv.NewWriter(w)
+	count := 0
+
+	//headers go out with the first list, so a failing first query still gets a 500
+	begin := func() {
+		w.Header().Set("Content-Disposition", "attachment; filename=todos."+format)
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.WriteHeader(200)
+			writer.Write(csvHeader)
+			writer.Flush()
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		io.WriteString(w, "[")
+	}
+
+	err := model.ExportLists(utils.RequestContext(r), user.ID, func(list model.ExportList) error {
+		if count == 0 {
+			begin()
+		}
+		count++
+
+		if format == "csv" {
+			if len(list.Tasks) == 0 {
+				writer.Write(csvRow(list.ToDo, nil))
+			}
+			for i := range list.Tasks {
+				writer.Write(csvRow(list.ToDo, &list.Tasks[i]))
+			}
+			writer.Flush()
+
+			return writer.Error()
+		}
+
+		if count > 1 {
+			io.WriteString(w, ",")
+		}
+
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(data)
+
+		return err
+	})
+
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("exporting lists")
+		if count == 0 {
+			utils.WriteJSON(w, "Unable to export lists", 500)
+		}
+		//a stream cut short is left unterminated, so clients can't take it for a whole export
+		return
+	}
+
+	if count == 0 {
+		begin()
+	}
+
+	if format == "json" {
+		io.WriteString(w, "]")
+	}
+}
+
+//Import creates lists and tasks from a JSON or CSV export, dryRun=true only reports ...
+func (tdc ToDoController) Import(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := context.Get(r, "user").(model.User)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			format = "csv"
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var lists []model.ExportList
+	var err error
+
+	switch format {
+	case "json":
+		err = json.NewDecoder(body).Decode(&lists)
+	case "csv":
+		lists, err = readCSV(body)
+	default:
+		err = errors.New("Format must be json or csv")
+	}
+
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), 400)
+		return
+	}
+
+	for _, list := range lists {
+		if strings.TrimSpace(list.Name) == "" {
+			utils.WriteJSON(w, "Every list needs a name", 400)
+			return
+		}
+	}
+
+	report, err := model.ImportLists(utils.RequestContext(r), user.ID, lists, dryRun)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("importing lists")
+		utils.WriteJSON(w, "Import failed", 500)
+		return
+	}
+
+	if !dryRun {
+		for _, list := range report.Lists {
+			if list.Status != "created" {
+				continue
+			}
+			if todo, err := model.GetAnyToDo(utils.RequestContext(r), list.NewID); err == nil {
+				model.PublishToDoEvent("todo.created", todo, todo)
+			}
+		}
+		for _, task := range report.Tasks {
+			if task.Status == "created" {
+				model.PublishTaskEvent(utils.RequestContext(r), "task.created", task.ToDoID, task.NewID)
+			}
+		}
+	}
+
+	utils.WriteJSON(w, report, 200)
+}
+
+func csvRow(todo model.ToDo, task *model.Task) []string {
+	row := []string{strconv.Itoa(todo.ID), todo.Name, todo.Description, "", "", "", "", "", "", ""}
+
+	if task != nil {
+		row[3] = strconv.Itoa(task.ID)
+		row[4] = task.Name
+		row[5] = task.DateCreated
+		row[6] = task.DateFinish
+		row[7] = task.Priority
+		row[8] = strconv.FormatBool(task.Status)
+		row[9] = task.DateDone
+	}
+
+	return row
+}
+
+//readCSV groups rows by todo_id, or by todo_name when the id is empty ...
+func readCSV(r io.Reader) ([]model.ExportList, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("Missing CSV header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	if _, ok := columns["todo_name"]; !ok {
+		return nil, errors.New("CSV header must contain todo_name")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var lists []model.ExportList
+	index := make(map[string]int)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key := field(record, "todo_id")
+		if key == "" {
+			key = "name:" + field(record, "todo_name")
+		}
+
+		i, ok := index[key]
+		if !ok {
+			todoID, _ := strconv.Atoi(field(record, "todo_id"))
+
+			lists = append(lists, model.ExportList{
+				ToDo: model.ToDo{
+					ID:          todoID,
+					Name:        field(record, "todo_name"),
+					Description: field(record, "todo_description"),
+				},
+				Tasks: []model.Task{},
+			})
+			i = len(lists) - 1
+			index[key] = i
+		}
+
+		if field(record, "task_name") == "" {
+			continue
+		}
+
+		taskID, _ := strconv.Atoi(field(record, "task_id"))
+
+		status := false
+		if value := field(record, "status"); value != "" {
+			status, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: invalid status %q", line, value)
+			}
+		}
+
+		lists[i].Tasks = append(lists[i].Tasks, model.Task{
+			ID:          taskID,
+			Name:        field(record, "task_name"),
+			DateCreated: field(record, "date_created"),
+			DateFinish:  field(record, "date_finish"),
+			Priority:    field(record, "priority"),
+			Status:      status,
+			DateDone:    field(record, "date_done"),
+		})
+	}
+
+	return lists, nil
+}
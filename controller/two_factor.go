@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+)
+
+//recoveryCodes is how many one time codes a confirmed enrollment hands out
+const recoveryCodes = 10
+
+//EnrollTwoFactor starts a TOTP enrollment, the uri goes into a QR code for authenticator apps ...
+func (ac *AccountsController) EnrollTwoFactor(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	secret, err := utils.NewTOTPSecret()
+	if err == nil {
+		err = model.SaveTOTPSecret(utils.RequestContext(r), user.ID, secret, time.Now())
+	}
+
+	if err == model.ErrTwoFactorEnabled {
+		utils.WriteJSON(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("enrolling two-factor")
+		utils.WriteJSON(w, "Unable to start the enrollment", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]string{
+		"secret": secret,
+		"uri":    utils.TOTPURI(ac.conf.TOTPIssuer, user.Email, secret),
+	}, http.StatusOK)
+}
+
+//ConfirmTwoFactor turns 2FA on with a first code from the app and returns the recovery codes, only this once ...
+func (ac *AccountsController) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	code, ok := readCode(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := utils.RequestContext(r)
+	now := time.Now()
+
+	tf, err := model.GetTwoFactor(ctx, user.ID)
+	if err == sql.ErrNoRows {
+		utils.WriteJSON(w, "Start the enrollment first", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("loading two-factor")
+		utils.WriteJSON(w, "Unable to confirm two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if tf.Enabled != 0 {
+		utils.WriteJSON(w, model.ErrTwoFactorEnabled.Error(), http.StatusConflict)
+		return
+	}
+
+	step, ok := utils.CheckTOTP(tf.Secret, code, now)
+	if !ok {
+		utils.WriteJSON(w, model.ErrCodeInvalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes := make([]string, recoveryCodes)
+	for i := range codes {
+		token, err := utils.RandomToken(5)
+		if err != nil {
+			utils.WriteJSON(w, "Unable to confirm two-factor authentication", http.StatusInternalServerError)
+			return
+		}
+		codes[i] = token[:5] + "-" + token[5:]
+	}
+
+	err = model.EnableTwoFactor(ctx, user.ID, step, codes, now)
+	if err == model.ErrTwoFactorEnabled {
+		utils.WriteJSON(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("enabling two-factor")
+		utils.WriteJSON(w, "Unable to confirm two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RequestLogger(r).WithField("userID", user.ID).Info("two-factor enabled")
+
+	utils.WriteJSON(w, map[string][]string{"recovery_codes": codes}, http.StatusOK)
+}
+
+//DisableTwoFactor turns 2FA off, it takes the account password and a code or a recovery code so neither a
+//stolen token nor a guessed code alone can ...
+func (ac *AccountsController) DisableTwoFactor(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	user := gcontext.Get(r, "user").(model.User)
+
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Password == "" || strings.TrimSpace(body.Code) == "" {
+		utils.WriteJSON(w, "Body must carry the password and a code", http.StatusBadRequest)
+		return
+	}
+
+	ctx := utils.RequestContext(r)
+
+	err = user.CheckPassword(ctx, body.Password)
+	if err == model.ErrPasswordInvalid {
+		utils.WriteJSON(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("checking password")
+		utils.WriteJSON(w, "Unable to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	tf, err := model.GetTwoFactor(ctx, user.ID)
+	if err == nil {
+		err = model.CheckSecondFactor(ctx, tf, body.Code, time.Now())
+	}
+
+	switch err {
+	case nil:
+	case sql.ErrNoRows, model.ErrTwoFactorDisabled:
+		utils.WriteJSON(w, model.ErrTwoFactorDisabled.Error(), http.StatusConflict)
+		return
+	case model.ErrCodeInvalid:
+		utils.WriteJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		utils.RequestLogger(r).WithError(err).Error("checking second factor")
+		utils.WriteJSON(w, "Unable to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	err = model.DisableTwoFactor(ctx, user.ID)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("disabling two-factor")
+		utils.WriteJSON(w, "Unable to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RequestLogger(r).WithField("userID", user.ID).Info("two-factor disabled")
+
+	utils.WriteJSON(w, "Two-factor authentication is off", http.StatusOK)
+}
+
+func readCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || strings.TrimSpace(body.Code) == "" {
+		utils.WriteJSON(w, "Body must carry a code", http.StatusBadRequest)
+		return "", false
+	}
+
+	return body.Code, true
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -12,8 +13,13 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
+//emailFormat is checked on register and on email changes
+var emailFormat = regexp.MustCompile("^[a-zA-Z0-9_.+-]+@[a-zA-Z0-9-]+\\.[a-zA-Z0-9-.]+$")
+
 //Users struct .
-type Users struct{}
+type Users struct {
+	Accounts *AccountsController //mails the verification of new accounts
+}
 
 //Create ...
 func (uc Users) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -33,21 +39,25 @@ func (uc Users) Create(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		return
 	}
 
-	regexpEmail := regexp.MustCompile("^[a-zA-Z0-9_.+-]+@[a-zA-Z0-9-]+\\.[a-zA-Z0-9-.]+$")
-
-	if user.Email == "" || !regexpEmail.MatchString(user.Email) {
+	if user.Email == "" || !emailFormat.MatchString(user.Email) {
 		err = errors.New("Email is empty or wrong email format")
 		utils.WriteJSON(w, err.Error(), http.StatusNotAcceptable)
 		return
 	}
 
-	err = user.Create()
+	err = user.Create(utils.RequestContext(r))
 
 	if err != nil {
 		utils.WriteJSON(w, err, http.StatusBadRequest)
 		return
 	}
 
+	//the account exists either way, a failed mail is resent through /email/resend
+	err = uc.Accounts.SendVerification(utils.RequestContext(r), user, user.Email)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("creating email verification")
+	}
+
 	utils.WriteJSON(w, "Succesfully registered !", http.StatusOK)
 }
 
@@ -68,7 +78,7 @@ func (uc Users) ListAll(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 		return
 	}
 
-	users, err = model.ListUsers(user.ID)
+	users, err = model.ListUsers(utils.RequestContext(r), user.ID)
 
 	if err != nil {
 		utils.WriteJSON(w, "Error listing users", http.StatusInternalServerError)
@@ -93,7 +103,7 @@ func (uc Users) UpdatePassword(w http.ResponseWriter, r *http.Request, _ httprou
 
 		}
 	}
-	err := user.UpdatePassword(oldPass, newPass1)
+	err := user.UpdatePassword(utils.RequestContext(r), oldPass, newPass1)
 
 	if err != nil {
 		utils.WriteJSON(w, "Old passwords do not match", http.StatusBadRequest)
@@ -129,7 +139,7 @@ func (uc Users) UpdatePassword2(w http.ResponseWriter, r *http.Request, _ httpro
 		}
 	}
 
-	err = user.UpdatePassword(par.Oldpassword, par.Newpassword)
+	err = user.UpdatePassword(utils.RequestContext(r), par.Oldpassword, par.Newpassword)
 	if err != nil {
 		utils.WriteJSON(w, "Error updating password", http.StatusInternalServerError)
 		return
@@ -151,7 +161,7 @@ func (uc Users) UpdateType(w http.ResponseWriter, r *http.Request, _ httprouter.
 
 	if user.IsAdmin() && u.Type != "" {
 
-		err = u.UpdateType()
+		err = u.UpdateType(utils.RequestContext(r))
 
 		if err != nil {
 			utils.WriteJSON(w, "Error updating user type", http.StatusInternalServerError)
@@ -175,7 +185,7 @@ func (uc Users) DeleteUser(w http.ResponseWriter, r *http.Request, params httpro
 
 	userID := params.ByName("id")
 
-	err := user.DeleteUser(userID)
+	err := user.DeleteUser(utils.RequestContext(r), userID)
 
 	if err != nil {
 		utils.WriteJSON(w, "Error deleting user", http.StatusInternalServerError)
@@ -196,7 +206,7 @@ func (uc Users) GetUser(w http.ResponseWriter, r *http.Request, params httproute
 		return
 	}
 
-	user, err := user.GetUser(userID)
+	user, err := user.GetUser(utils.RequestContext(r), userID)
 	if err != nil {
 		utils.WriteJSON(w, err, http.StatusInternalServerError)
 	}
@@ -204,12 +214,18 @@ func (uc Users) GetUser(w http.ResponseWriter, r *http.Request, params httproute
 	utils.WriteJSON(w, user, http.StatusOK)
 }
 
-//Logout ...
+//Refresh returns the new access and refresh tokens, Provider.JWT rotated them ...
+func (uc Users) Refresh(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	u := context.Get(r, "user").(model.User)
+	utils.WriteJSON(w, u, http.StatusOK)
+}
+
+//Logout ends the session of the token, other devices stay logged in ...
 func (uc Users) Logout(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	user := context.Get(r, "user").(model.User)
-	err := user.Clear()
+	err := model.RevokeSession(utils.RequestContext(r), user.ID, user.SessionID)
 
-	if err != nil {
+	if err != nil && err != sql.ErrNoRows {
 		utils.WriteJSON(w, "Error logging out", http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,11 @@
+//Package docs holds the OpenAPI description of the REST API ...
+package docs
+
+import (
+	_ "embed"
+)
+
+//OpenAPI is built into the binary, so the server finds it whatever directory it runs from
+//
+//go:embed openapi.json
+var OpenAPI []byte
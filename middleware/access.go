@@ -1,9 +1,15 @@
 package middlleware
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -12,16 +18,19 @@ import (
 	jwtreq "github.com/dgrijalva/jwt-go/request"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 
 	"github.com/bicom/todos/model"
 	"github.com/bicom/todos/utils"
 	"github.com/dgrijalva/jwt-go"
-	"github.com/gorilla/context"
+	gcontext "github.com/gorilla/context"
 )
 
-var (
-	pubkey []byte
-)
+//maxAuthBody limits the bodies JWT reads itself, for /token/refresh and /login/2fa
+const maxAuthBody = 4096
+
+//challengeTTL is how long the second step of a login may take
+const challengeTTL = 5 * time.Minute
 
 //Provider ...
 type Provider struct {
@@ -32,29 +41,111 @@ type Provider struct {
 type Middlleware struct{}
 
 var (
-	jwtKey = []byte("mykey")
+	//jwtKeys, accessTTL and refreshTTL are set from the configuration with SetJWT
+	jwtKeys    *utils.KeySet
+	accessTTL  = 15 * time.Minute
+	refreshTTL = 30 * 24 * time.Hour
+	//allowedOrigins is set with SetAllowedOrigins, "*" allows any
+	allowedOrigins = []string{"*"}
+	//trustedProxies is set with SetTrustedProxies, only their X-Forwarded-For is read
+	trustedProxies []*net.IPNet
+	//requireVerified is set with RequireVerifiedEmail
+	requireVerified bool
+
+	//browsers can't set headers on EventSource and WebSocket requests
+	tokenExtractor = jwtreq.MultiExtractor{jwtreq.AuthorizationHeaderExtractor, queryTokenExtractor{}}
 )
 
+//queryTokenRoutes are the streams that may send the token as access_token, URLs end up in logs
+//and histories so no other route takes it there
+var queryTokenRoutes = map[string]bool{"/events": true, "/ws": true}
+
+//queryTokenExtractor reads the token from the access_token argument of GET requests to queryTokenRoutes ...
+type queryTokenExtractor struct{}
+
+//ExtractToken ...
+func (e queryTokenExtractor) ExtractToken(r *http.Request) (string, error) {
+	if r.Method != "GET" || !queryTokenRoutes[r.URL.Path] {
+		return "", jwtreq.ErrNoTokenInRequest
+	}
+
+	if token := r.URL.Query().Get("access_token"); token != "" {
+		return token, nil
+	}
+
+	return "", jwtreq.ErrNoTokenInRequest
+}
+
 var (
 	// ErrUserTypeNotDefine ..
 	ErrUserTypeNotDefine = errors.New("User Type not set on account")
 	// ErrUserNotFound user not activated
 	ErrUserNotFound = errors.New("This user doesn't exists")
+	//ErrEmailNotVerified ...
+	ErrEmailNotVerified = errors.New("Verify your email before logging in")
 )
 
+//TwoFactorRequired is returned for a right password of a user with 2FA on, the challenge
+//and a code log in at /login/2fa ...
+type TwoFactorRequired struct {
+	Message   string `json:"message"`
+	Challenge string `json:"challenge"`
+	Expires   int64  `json:"expires"`
+}
+
+func (e TwoFactorRequired) Error() string {
+	return e.Message
+}
+
 // Clear ...
 func (m Middlleware) Clear(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
-	defer context.Clear(req)
+	defer gcontext.Clear(req)
 
 	next(res, req)
 }
 
+//SetAllowedOrigins sets the origins CORS and the WebSocket upgrade accept, "*" accepts any ...
+func SetAllowedOrigins(origins []string) {
+	allowedOrigins = origins
+}
+
+//SetTrustedProxies sets the proxies whose X-Forwarded-For names the client ...
+func SetTrustedProxies(networks []*net.IPNet) {
+	trustedProxies = networks
+}
+
+//OriginAllowed accepts requests without an Origin, from the own host and from allowedOrigins ...
+func OriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
 // CORS ...
 func (m Middlleware) CORS(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
 	// CORS support for Preflighted requests
-	res.Header().Set("Access-Control-Allow-Origin", "*")
+	if len(allowedOrigins) == 1 && allowedOrigins[0] == "*" {
+		res.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		res.Header().Add("Vary", "Origin")
+		if origin := req.Header.Get("Origin"); origin != "" && OriginAllowed(req) {
+			res.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+	}
 	res.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PUT, PATCH, DELETE")
-	res.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	res.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key, X-Request-ID")
+	res.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy")
 
 	next(res, req)
 }
@@ -69,14 +160,23 @@ func (m Middlleware) Preflight(res http.ResponseWriter, req *http.Request, next
 	next(res, req)
 }
 
-// SetRBAC ...
+//SetJWT sets the keys signing and checking the tokens, how long access tokens last
+//and how long a session lasts without a refresh ...
+func SetJWT(keys *utils.KeySet, access, refresh time.Duration) {
+	jwtKeys = keys
+	accessTTL = access
+	refreshTTL = refresh
+}
+
+//RequireVerifiedEmail refuses logins of users who haven't verified their email ...
+func RequireVerifiedEmail(require bool) {
+	requireVerified = require
+}
+
+// SetRBAC loads the casbin model and policy, relative paths start at the working directory ...
 func (m *Provider) SetRBAC(model, policies string) error {
 	var err error
-	path, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-	m.rules, err = casbin.NewEnforcerSafe(path+model, path+policies, false)
+	m.rules, err = casbin.NewEnforcerSafe(model, policies, false)
 
 	if err != nil {
 		return err
@@ -85,12 +185,95 @@ func (m *Provider) SetRBAC(model, policies string) error {
 	return nil
 }
 
+//Loaded tells if SetRBAC read the rules ...
+func (m Provider) Loaded() bool {
+	return m.rules != nil
+}
+
 //JWT ...
 func (m Provider) JWT(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	//Checking uri strings ...
 	if strings.Contains(r.RequestURI, "/register") && r.Method == "POST" {
 		next(w, r)
 		return
+	} else if r.URL.Path == "/openapi.json" && r.Method == "GET" {
+		next(w, r)
+		return
+	} else if (r.URL.Path == "/healthz" || r.URL.Path == "/readyz") && r.Method == "GET" {
+		//probes run without credentials
+		next(w, r)
+		return
+	} else if (r.URL.Path == "/password/forgot" || r.URL.Path == "/password/reset" ||
+		r.URL.Path == "/email/verify" || r.URL.Path == "/email/resend") && r.Method == "POST" {
+		//the mailed tokens stand in for the password
+		next(w, r)
+		return
+	} else if r.URL.Path == "/.well-known/jwks.json" && r.Method == "GET" {
+		next(w, r)
+		return
+	} else if r.URL.Path == "/metrics" && r.Method == "GET" {
+		//metrics check their own token or network
+		next(w, r)
+		return
+	} else if strings.HasPrefix(r.URL.Path, "/calendar/") && r.Method == "GET" {
+		//calendar feeds are authorized by their secret token
+		next(w, r)
+		return
+	} else if r.URL.Path == "/token/refresh" && r.Method == "POST" {
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+
+		if !readBody(r, &body) || body.RefreshToken == "" {
+			utils.WriteJSON(w, "Body must carry a refresh_token", http.StatusBadRequest)
+			return
+		}
+
+		user, err := refreshToken(utils.RequestContext(r), body.RefreshToken)
+
+		switch err {
+		case nil:
+		case model.ErrRefreshTokenInvalid, model.ErrRefreshTokenReused, model.ErrSessionEnded:
+			utils.WriteJSON(w, err.Error(), http.StatusUnauthorized)
+			return
+		default:
+			utils.RequestLogger(r).WithError(err).Error("refreshing token")
+			utils.WriteJSON(w, "Unable to refresh the token", http.StatusInternalServerError)
+			return
+		}
+
+		user.SetPermissions(m.rules)
+
+		gcontext.Set(r, "user", user)
+		next(w, r)
+	} else if r.URL.Path == "/login/2fa" && r.Method == "POST" {
+		var body struct {
+			Challenge string `json:"challenge"`
+			Code      string `json:"code"`
+		}
+
+		if !readBody(r, &body) || body.Challenge == "" || body.Code == "" {
+			utils.WriteJSON(w, "Body must carry a challenge and a code", http.StatusBadRequest)
+			return
+		}
+
+		user, err := LoginSecondFactor(utils.RequestContext(r), body.Challenge, body.Code, model.Session{UserAgent: r.UserAgent(), IP: clientIP(r)})
+
+		switch err {
+		case nil:
+		case model.ErrChallengeInvalid, model.ErrCodeInvalid, model.ErrTwoFactorDisabled:
+			utils.WriteJSON(w, err.Error(), http.StatusUnauthorized)
+			return
+		default:
+			utils.RequestLogger(r).WithError(err).Error("checking second factor")
+			utils.WriteJSON(w, "Unable to log in", http.StatusInternalServerError)
+			return
+		}
+
+		user.SetPermissions(m.rules)
+
+		gcontext.Set(r, "user", user)
+		next(w, r)
 	} else if strings.Contains(r.RequestURI, "/login") {
 
 		username, password, ok := r.BasicAuth()
@@ -102,8 +285,17 @@ func (m Provider) JWT(w http.ResponseWriter, r *http.Request, next http.HandlerF
 
 		user := model.User{Username: username, Password: password}
 
-		user, _, err := issueToken(r, user)
+		user, err := issueToken(utils.RequestContext(r), user, model.Session{UserAgent: r.UserAgent(), IP: clientIP(r)})
+		countLogin(err)
 
+		if challenge, ok := err.(TwoFactorRequired); ok {
+			utils.WriteJSON(w, challenge, http.StatusUnauthorized)
+			return
+		}
+		if err == ErrEmailNotVerified {
+			utils.WriteJSON(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if err != nil {
 			utils.WriteJSON(w, err, http.StatusForbidden)
 			return
@@ -111,14 +303,14 @@ func (m Provider) JWT(w http.ResponseWriter, r *http.Request, next http.HandlerF
 
 		user.SetPermissions(m.rules)
 
-		context.Set(r, "user", user)
+		gcontext.Set(r, "user", user)
 		next(w, r)
 	} else if strings.Contains(r.RequestURI, "/logout") {
 		user, _, _ := checkToken(r)
 
 		user.SetPermissions(m.rules)
 
-		context.Set(r, "user", user)
+		gcontext.Set(r, "user", user)
 		next(w, r)
 	} else {
 		user, _, err := checkToken(r)
@@ -147,45 +339,130 @@ func (m Provider) JWT(w http.ResponseWriter, r *http.Request, next http.HandlerF
 			action += strings.Split(uri[0], "/")[1]
 		}
 
-		fmt.Println(fullAction)
-		fmt.Println(action)
+		utils.RequestLogger(r).WithFields(logrus.Fields{"route": fullAction, "action": action}).Debug("checking access")
 
 		user.SetPermissions(m.rules)
 
-		var userAllowedByRole = false
+		if !user.TokenAllows(fullAction, requestMethod2Mode(r.Method)) {
+			utils.WriteJSON(w, "The scopes of the API token don't allow this", http.StatusForbidden)
+			return
+		}
 
 		if action == "/users" || action == "/user" || action == "/todo" ||
 			action == "/todos" || action == "/task" || action == "/tasks" {
-			if m.rules != nil {
-				if m.rules.Enforce(user.Type, fullAction, requestMethod2Mode(r.Method)) {
-					fmt.Println("RBAC allowed by type " + user.Type + " and email " + user.Email + " for action " + fullAction)
-					userAllowedByRole = true
-				}
-			}
-			if !userAllowedByRole {
-				if m.rules != nil && m.rules.Enforce(user.Type, fullAction, requestMethod2Mode(r.Method)) {
-					fmt.Println("RBAC allowed by type " + user.Type + "for action" + fullAction)
-				} else if m.rules != nil && !m.rules.Enforce(user.Email, fullAction, requestMethod2Mode(r.Method)) {
-					fmt.Println("Forbidden RBAC per Email for action"+fullAction, errors.New("Forbidden RBAC"))
-					utils.WriteJSON(w, "Acces Forbidden", http.StatusForbidden)
-					return
-				}
+			_, span := utils.Tracer().Start(utils.RequestContext(r), "rbac "+action)
+			allowed := m.Allowed(user, fullAction, requestMethod2Mode(r.Method))
+			span.End()
+
+			if !allowed {
+				utils.WriteJSON(w, "Acces Forbidden", http.StatusForbidden)
+				return
 			}
 		}
-		context.Set(r, "user", user)
+		gcontext.Set(r, "user", user)
 
 		next(w, r)
 	}
 
 }
 
+//Allowed checks the RBAC rules for the path, first by user type and then by email ...
+func (m Provider) Allowed(user model.User, path string, mode string) bool {
+	if m.rules == nil {
+		return true
+	}
+
+	if m.rules.Enforce(user.Type, path, mode) {
+		utils.Logger.WithFields(logrus.Fields{"type": user.Type, "email": user.Email, "route": path}).Debug("RBAC allowed")
+		return true
+	}
+
+	if !m.rules.Enforce(user.Email, path, mode) {
+		utils.Logger.WithFields(logrus.Fields{"type": user.Type, "email": user.Email, "route": path}).Debug("RBAC forbidden")
+		utils.RBACDenied.WithLabelValues("/"+strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0], mode).Inc()
+		return false
+	}
+
+	return true
+}
+
+//ValidateToken returns the user a token was issued to, for callers outside of HTTP ...
+func ValidateToken(ctx context.Context, tokenString string) (model.User, error) {
+	if strings.HasPrefix(tokenString, model.APITokenPrefix) {
+		return parseAPIToken(ctx, tokenString)
+	}
+
+	user, _, err := parseToken(ctx, tokenString)
+
+	return user, err
+}
+
+//Login checks the credentials and starts a session the same way /login does, client describes the device ...
+func Login(ctx context.Context, username, password string, client model.Session) (model.User, error) {
+	user, err := issueToken(ctx, model.User{Username: username, Password: password}, client)
+	countLogin(err)
+
+	return user, err
+}
+
+//LoginSecondFactor finishes a login that got a TwoFactorRequired, code is a TOTP or a recovery code ...
+func LoginSecondFactor(ctx context.Context, challenge, code string, client model.Session) (model.User, error) {
+	user, err := secondFactor(ctx, challenge, code, client)
+	countLogin(err)
+
+	return user, err
+}
+
+//countLogin records the outcome of a login attempt, a challenge waits for the second factor ...
+func countLogin(err error) {
+	result := "success"
+	if _, ok := err.(TwoFactorRequired); ok {
+		result = "challenge"
+	} else if err != nil {
+		result = "failure"
+	}
+
+	utils.LoginAttempts.WithLabelValues(result).Inc()
+}
+
 func checkToken(r *http.Request) (model.User, *jwt.Token, error) {
-	token, err := jwtreq.ParseFromRequest(r, jwtreq.AuthorizationHeaderExtractor, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("Client is not using the correct algorithm")
-		}
-		return jwtKey, nil
-	})
+	tokenString, err := tokenExtractor.ExtractToken(r)
+	if err != nil {
+		return model.User{}, nil, errors.New("Invalid token")
+	}
+
+	if strings.HasPrefix(tokenString, model.APITokenPrefix) {
+		user, err := parseAPIToken(utils.RequestContext(r), tokenString)
+		return user, nil, err
+	}
+
+	return parseToken(utils.RequestContext(r), tokenString)
+}
+
+//parseAPIToken returns the owner of a personal access token, limited to its scopes ...
+func parseAPIToken(ctx context.Context, tokenString string) (model.User, error) {
+	var user model.User
+
+	token, err := model.UseAPIToken(ctx, tokenString, time.Now())
+	if err != nil {
+		return user, err
+	}
+
+	user, err = user.GetUser(ctx, strconv.Itoa(token.UserID))
+	if err != nil {
+		return user, errors.New("User for token not found")
+	}
+
+	user.APITokenID = token.ID
+	user.Scopes = token.Scopes
+	user.Token = tokenString
+	user.Issued = token.Expires
+
+	return user, nil
+}
+
+func parseToken(ctx context.Context, tokenString string) (model.User, *jwt.Token, error) {
+	token, err := jwtKeys.Parse(tokenString)
 
 	var user model.User
 	if err != nil || !token.Valid {
@@ -194,88 +471,221 @@ func checkToken(r *http.Request) (model.User, *jwt.Token, error) {
 
 	claims := token.Claims.(jwt.MapClaims)
 
-	err = user.IsLoggedIn(claims["usr"].(string))
+	username, _ := claims["usr"].(string)
+	sessionID, _ := claims["sid"].(string)
+
+	err = user.IsLoggedIn(ctx, username)
 
 	if err != nil {
 		return user, token, errors.New("User for token not found")
 	}
-	if user.Issued != 0 && time.Now().After(time.Unix(user.Issued, 0)) {
-		return user, token, errors.New("Token expired")
+
+	//a revoked session ends its access tokens before they expire
+	session, err := model.GetSession(ctx, sessionID)
+	if err != nil || session.UserID != user.ID || !session.Active(time.Now()) {
+		return user, token, model.ErrSessionEnded
 	}
-	if user.Issued == 0 {
-		return user, token, errors.New("User logged out")
+
+	user.Token = tokenString
+	user.SessionID = sessionID
+	if exp, ok := claims["exp"].(float64); ok {
+		user.Issued = int64(exp)
 	}
+
 	return user, token, nil
 }
 
-func issueToken(r *http.Request, m model.User) (model.User, *jwt.Token, error) {
+func issueToken(ctx context.Context, m model.User, client model.Session) (model.User, error) {
 	if m.Username == "" || m.Password == "" {
 		err := errors.New("Missing username or password")
 
-		return m, nil, err
+		return m, err
 	}
 
-	m, err := m.Login()
+	m, err := m.Login(ctx)
 
 	if err != nil {
-		return m, nil, err
+		return m, err
 	}
 
 	if m.ID == 0 {
-		return m, nil, ErrUserNotFound
+		return m, ErrUserNotFound
 	}
 	if m.Type == "" {
-		return m, nil, ErrUserTypeNotDefine
+		return m, ErrUserTypeNotDefine
+	}
+	if requireVerified && m.EmailVerified == 0 {
+		return m, ErrEmailNotVerified
 	}
 
-	if time.Now().Before(time.Unix(m.Issued, 0)) {
-		return m, nil, nil
+	tf, err := model.GetTwoFactor(ctx, m.ID)
+	if err == nil && tf.Enabled != 0 {
+		return m, newChallenge(ctx, m)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return m, err
 	}
 
-	issued := time.Now().Add(time.Hour * 48).Unix()
+	return startSession(ctx, m, client)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"usr": m.Username,
-		"exp": issued,
-	})
+//newChallenge returns the TwoFactorRequired for a login of m, or the error storing it ...
+func newChallenge(ctx context.Context, m model.User) error {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
 
-	tokenString, err := token.SignedString(jwtKey)
+	now := time.Now()
+	expires := now.Add(challengeTTL)
 
+	err = model.CreateLoginChallenge(ctx, m.ID, token, now, expires)
 	if err != nil {
-		return m, nil, err
+		return err
 	}
 
-	m.Token = tokenString
-	m.Issued = issued
+	return TwoFactorRequired{Message: "Two-factor code required", Challenge: token, Expires: expires.Unix()}
+}
+
+//secondFactor checks code against the user of challenge and starts the session ...
+func secondFactor(ctx context.Context, challenge, code string, client model.Session) (model.User, error) {
+	var m model.User
+
+	now := time.Now()
+
+	userID, err := model.AttemptLoginChallenge(ctx, challenge, now)
+	if err != nil {
+		return m, err
+	}
+
+	tf, err := model.GetTwoFactor(ctx, userID)
+	if err == sql.ErrNoRows {
+		return m, model.ErrTwoFactorDisabled
+	}
+	if err != nil {
+		return m, err
+	}
+
+	err = model.CheckSecondFactor(ctx, tf, code, now)
+	if err != nil {
+		return m, err
+	}
+
+	err = model.DeleteLoginChallenge(ctx, challenge)
+	if err != nil {
+		return m, err
+	}
+
+	m, err = m.GetUser(ctx, strconv.Itoa(userID))
+	if err != nil {
+		return m, err
+	}
+
+	return startSession(ctx, m, client)
+}
+
+//startSession creates the session of a login that passed every check ...
+func startSession(ctx context.Context, m model.User, client model.Session) (model.User, error) {
+	now := time.Now()
+
+	session := model.Session{
+		UserID:    m.ID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		Created:   now.Unix(),
+		LastUsed:  now.Unix(),
+		Expires:   now.Add(refreshTTL).Unix(),
+	}
+	if len(session.UserAgent) > 255 {
+		session.UserAgent = session.UserAgent[:255]
+	}
+
+	var err error
+
+	session.ID, err = utils.RandomToken(16)
+	if err != nil {
+		return m, err
+	}
+
+	refresh, err := utils.RandomToken(32)
+	if err != nil {
+		return m, err
+	}
+
+	err = model.CreateSession(ctx, session, refresh)
+	if err != nil {
+		return m, err
+	}
+
+	return signAccessToken(m, session, refresh, now)
+}
+
+//refreshToken rotates the refresh token and issues a new access token for its session ...
+func refreshToken(ctx context.Context, refresh string) (model.User, error) {
+	var user model.User
+
+	next, err := utils.RandomToken(32)
+	if err != nil {
+		return user, err
+	}
+
+	now := time.Now()
+
+	session, err := model.RotateRefreshToken(ctx, refresh, next, now, now.Add(refreshTTL))
+	if err != nil {
+		return user, err
+	}
+
+	user, err = user.GetUser(ctx, strconv.Itoa(session.UserID))
+	if err != nil {
+		return user, err
+	}
+
+	return signAccessToken(user, session, next, now)
+}
+
+//signAccessToken puts the short lived access token and the refresh token of session on m ...
+func signAccessToken(m model.User, session model.Session, refresh string, now time.Time) (model.User, error) {
+	issued := now.Add(accessTTL).Unix()
+
+	tokenString, err := jwtKeys.Sign(jwt.MapClaims{
+		"usr": m.Username,
+		"sid": session.ID,
+		"iat": now.Unix(),
+		"exp": issued,
+	})
 
-	err = m.UpdateTokenInfo()
 	if err != nil {
-		return m, nil, err
+		return m, err
 	}
 
+	m.Token = tokenString
+	m.Issued = issued
+	m.SessionID = session.ID
+	m.RefreshToken = refresh
+	m.RefreshExpires = session.Expires
 	m.Password = ""
 
-	return m, nil, nil
+	return m, nil
 }
 
 //CheckTodo ...
 func (m Middlleware) CheckTodo(h httprouter.Handle) httprouter.Handle {
 
 	return func(res http.ResponseWriter, req *http.Request, params httprouter.Params) {
-		user := context.Get(req, "user").(model.User)
+		user := gcontext.Get(req, "user").(model.User)
 
 		todoID, err := strconv.Atoi(params.ByName("id"))
 
-		todo, err := model.GetAnyToDo(todoID)
+		_, err = model.CheckToDoAccess(utils.RequestContext(req), user, todoID)
 
-		if err != nil {
-			fmt.Println("The list is not reachable")
-			utils.WriteJSON(res, err, 400)
+		if err == model.ErrListForbidden {
+			utils.WriteJSON(res, err.Error(), 403)
 			return
 		}
-
-		if todo.UserID != user.ID && !user.IsAdmin() {
-			utils.WriteJSON(res, "You are not allowed to make any changes to this list", 403)
+		if err != nil {
+			utils.RequestLogger(req).WithError(err).Warn("The list is not reachable")
+			utils.WriteJSON(res, err, 400)
 			return
 		}
 
@@ -287,53 +697,38 @@ func (m Middlleware) CheckTodo(h httprouter.Handle) httprouter.Handle {
 func (m Middlleware) CheckTask(h httprouter.Handle) httprouter.Handle {
 
 	return func(res http.ResponseWriter, req *http.Request, params httprouter.Params) {
-		user := context.Get(req, "user").(model.User)
+		user := gcontext.Get(req, "user").(model.User)
 
 		if req.Method == "POST" || req.Method == "GET" {
 
 			todoID, err := strconv.Atoi(params.ByName("id"))
 
-			var todo model.ToDo
+			_, err = model.CheckToDoAccess(utils.RequestContext(req), user, todoID)
 
-			todo, err = model.GetAnyToDo(todoID)
-
-			if err != nil {
-				fmt.Println("The list is not reachable")
-				utils.WriteJSON(res, err, 400)
+			if err == model.ErrListForbidden {
+				utils.WriteJSON(res, err.Error(), 403)
 				return
 			}
-
-			if todo.UserID != user.ID && user.Type != "admin" {
-				utils.WriteJSON(res, "You are not allowed to make any changes to this list", 403)
+			if err != nil {
+				utils.RequestLogger(req).WithError(err).Warn("The list is not reachable")
+				utils.WriteJSON(res, err, 400)
 				return
 			}
 		} else if req.Method == "DELETE" {
 
 			taskID, err := strconv.Atoi(params.ByName("id"))
 
-			var task model.Task
-			var todo model.ToDo
-
-			task, err = model.GetAnyTask(taskID)
+			_, _, err = model.CheckTaskAccess(utils.RequestContext(req), user, taskID)
 
-			if err != nil {
-				fmt.Println("The task is not reachable")
-				utils.WriteJSON(res, err, 400)
+			if err == model.ErrListForbidden {
+				utils.WriteJSON(res, err.Error(), 403)
 				return
 			}
-
-			todo, err = model.GetAnyToDo(task.ToDoID)
-
 			if err != nil {
-				fmt.Println("The list is not reachable")
+				utils.RequestLogger(req).WithError(err).Warn("The task is not reachable")
 				utils.WriteJSON(res, err, 400)
 				return
 			}
-
-			if todo.UserID != user.ID && !user.IsAdmin() {
-				utils.WriteJSON(res, "You are not allowed to make any changes to this list", 403)
-				return
-			}
 		}
 
 		h(res, req, params)
@@ -347,3 +742,11 @@ func requestMethod2Mode(reqMethod string) string {
 
 	return "read"
 }
+
+//readBody decodes the JSON body into v and puts it back for the handlers after JWT ...
+func readBody(r *http.Request, v interface{}) bool {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAuthBody))
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	return err == nil && json.Unmarshal(data, v) == nil
+}
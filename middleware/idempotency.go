@@ -0,0 +1,178 @@
+package middlleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+)
+
+//maxIdempotencyKey is the longest Idempotency-Key that is accepted ...
+const maxIdempotencyKey = 255
+
+//idempotencyCleanup is how often expired keys are dropped from the table ...
+const idempotencyCleanup = time.Minute
+
+//maxIdempotentBody is as much as the largest request, an import, may send
+const maxIdempotentBody = 10 << 20
+
+//secretResponses carry tokens or secrets, they are never stored for replays ...
+var secretResponses = map[string]bool{
+	"/login":          true,
+	"/login/2fa":      true,
+	"/token/refresh":  true,
+	"/me/2fa":         true,
+	"/me/2fa/confirm": true,
+	"/me/tokens":      true, //the new API token is only shown once
+}
+
+//Idempotency replays the stored response of POST requests that repeat an Idempotency-Key ...
+type Idempotency struct {
+	TTL time.Duration
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+	cleanups    sync.WaitGroup
+}
+
+//NewIdempotency keeps responses for ttl ...
+func NewIdempotency(ttl time.Duration) *Idempotency {
+	return &Idempotency{TTL: ttl, lastCleanup: time.Now()}
+}
+
+//Handle has to run after JWT, keys are scoped to the user ...
+func (m *Idempotency) Handle(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get("Idempotency-Key")
+
+	user, ok := gcontext.Get(r, "user").(model.User)
+
+	if r.Method != "POST" || key == "" || !ok || user.ID == 0 || secretResponses[r.URL.Path] {
+		next(w, r)
+		return
+	}
+
+	if len(key) > maxIdempotencyKey {
+		utils.WriteJSON(w, fmt.Sprintf("Idempotency-Key can't be longer than %d characters", maxIdempotencyKey), http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+	if _, tooLarge := err.(*http.MaxBytesError); tooLarge {
+		utils.WriteJSON(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	requestHash := hex.EncodeToString(hash.Sum(nil))
+
+	m.cleanup(time.Now())
+
+	stored, err := model.ReserveIdempotencyKey(utils.RequestContext(r), user.ID, key, requestHash, m.TTL)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("reserving Idempotency-Key")
+		utils.WriteJSON(w, "Unable to check Idempotency-Key", http.StatusInternalServerError)
+		return
+	}
+
+	if stored != nil {
+		if stored.RequestHash != requestHash {
+			utils.WriteJSON(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			return
+		}
+		if stored.Status == 0 {
+			utils.WriteJSON(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+			return
+		}
+
+		if stored.ContentType != "" {
+			w.Header().Set("Content-Type", stored.ContentType)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(stored.Status)
+		w.Write(stored.Body)
+		return
+	}
+
+	//a panic would leave the key in progress until it expires
+	defer func() {
+		if p := recover(); p != nil {
+			err := model.ReleaseIdempotencyKey(utils.RequestContext(r), user.ID, key)
+			if err != nil {
+				utils.RequestLogger(r).WithError(err).Error("releasing Idempotency-Key")
+			}
+			panic(p)
+		}
+	}()
+
+	rec := &teeRecorder{ResponseWriter: w, status: http.StatusOK}
+	next(rec, r)
+
+	//server errors are not stored, the client may retry them with the same key
+	if rec.status >= 500 {
+		err = model.ReleaseIdempotencyKey(utils.RequestContext(r), user.ID, key)
+	} else {
+		err = model.SaveIdempotentResponse(utils.RequestContext(r), user.ID, key, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+	}
+
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("storing idempotent response")
+	}
+}
+
+//teeRecorder passes the response through and keeps a copy of it ...
+type teeRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *teeRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *teeRecorder) Write(data []byte) (int, error) {
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+//cleanup drops expired keys in the background, at most once per idempotencyCleanup ...
+func (m *Idempotency) cleanup(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastCleanup) < idempotencyCleanup {
+		return
+	}
+	m.lastCleanup = now
+
+	m.cleanups.Add(1)
+	go func() {
+		defer m.cleanups.Done()
+
+		if err := model.DeleteExpiredIdempotencyKeys(context.Background(), now.Add(-m.TTL)); err != nil {
+			utils.Logger.WithError(err).Error("idempotency cleanup")
+		}
+	}()
+}
+
+//Close waits for a running cleanup, so the DB can be closed after it ...
+func (m *Idempotency) Close() {
+	m.cleanups.Wait()
+}
@@ -0,0 +1,106 @@
+package middlleware
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	"github.com/gorilla/context"
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/negroni"
+)
+
+//requestIDPattern limits incoming X-Request-ID values to something safe to log ...
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+//RequestID keeps a valid X-Request-ID or creates one, and puts a logger carrying it on the request ...
+func (m Middlleware) RequestID(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	id := req.Header.Get("X-Request-ID")
+
+	if !requestIDPattern.MatchString(id) {
+		id, _ = utils.RandomToken(16)
+	}
+
+	res.Header().Set("X-Request-ID", id)
+
+	context.Set(req, "requestID", id)
+	context.Set(req, "logger", utils.Logger.WithField("request_id", id))
+
+	next(res, req)
+}
+
+//AccessLog writes one line per answered request, it has to run inside Clear and after RequestID ...
+type AccessLog struct {
+	Router *httprouter.Router
+}
+
+//Handle ...
+func (a AccessLog) Handle(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+
+	next(res, req)
+
+	status, size := http.StatusOK, 0
+	if rw, ok := res.(negroni.ResponseWriter); ok {
+		if rw.Status() != 0 {
+			status = rw.Status()
+		}
+		size = rw.Size()
+	}
+
+	fields := logrus.Fields{
+		"method":     req.Method,
+		"route":      RoutePattern(a.Router, req),
+		"path":       req.URL.Path,
+		"status":     status,
+		"bytes":      size,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"remote":     clientIP(req),
+	}
+
+	if user, ok := context.Get(req, "user").(model.User); ok && user.ID != 0 {
+		fields["user_id"] = user.ID
+	}
+
+	entry := utils.RequestLogger(req).WithFields(fields)
+
+	switch {
+	case status >= 500:
+		entry.Error("request")
+	case status >= 400:
+		entry.Warn("request")
+	default:
+		entry.Info("request")
+	}
+}
+
+//RoutePattern turns the path back into the registered route, /task/3 into /task/:id ...
+func RoutePattern(router *httprouter.Router, req *http.Request) string {
+	if router == nil {
+		return req.URL.Path
+	}
+
+	handle, params, _ := router.Lookup(req.Method, req.URL.Path)
+	if handle == nil {
+		return "unmatched"
+	}
+
+	segments := strings.Split(req.URL.Path, "/")
+
+	i := 0
+	for _, param := range params {
+		for ; i < len(segments); i++ {
+			if segments[i] == param.Value {
+				segments[i] = ":" + param.Key
+				i++
+				break
+			}
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,33 @@
+package middlleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+	"github.com/urfave/negroni"
+)
+
+//Metrics counts and times the requests by route template, so /task/3 and /task/4 share a series ...
+type Metrics struct {
+	Router *httprouter.Router
+}
+
+//Handle ...
+func (m Metrics) Handle(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+
+	next(res, req)
+
+	status := http.StatusOK
+	if rw, ok := res.(negroni.ResponseWriter); ok && rw.Status() != 0 {
+		status = rw.Status()
+	}
+
+	labels := []string{req.Method, RoutePattern(m.Router, req), strconv.Itoa(status)}
+
+	utils.HTTPRequests.WithLabelValues(labels...).Inc()
+	utils.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,104 @@
+package middlleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/bicom/todos/utils"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("text/markdown", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/calendar", openapi3filter.FileBodyDecoder)
+}
+
+//OpenAPIValidator checks requests and responses against the OpenAPI document, meant for dev mode ...
+type OpenAPIValidator struct {
+	router  routers.Router
+	options *openapi3filter.Options
+}
+
+//NewOpenAPIValidator ...
+func NewOpenAPIValidator(doc *openapi3.T) (*OpenAPIValidator, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		MultiError: true,
+		//tokens are checked by Provider.JWT
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return &OpenAPIValidator{router: router, options: options}, nil
+}
+
+//Validate answers requests that don't match the document with 400 and responses that don't with 500 ...
+func (v *OpenAPIValidator) Validate(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	route, params, err := v.router.FindRoute(r)
+	if err != nil {
+		//unknown routes are answered by the router
+		next(w, r)
+		return
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: params,
+		Route:      route,
+		Options:    v.options,
+	}
+
+	err = openapi3filter.ValidateRequest(r.Context(), input)
+	if err != nil {
+		utils.WriteJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//streams and upgrades can't be buffered
+	if route.Operation.Extensions["x-stream"] == true {
+		next(w, r)
+		return
+	}
+
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next(rec, r)
+
+	output := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 rec.Header(),
+		Options:                v.options,
+	}
+	output.SetBodyBytes(rec.body.Bytes())
+
+	err = openapi3filter.ValidateResponse(r.Context(), output)
+	if err != nil {
+		utils.RequestLogger(r).WithError(err).Error("response does not match the OpenAPI document")
+		utils.WriteJSON(w, "Response does not match the OpenAPI document: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
+
+//responseRecorder holds the response back until it has been validated ...
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	return rec.body.Write(data)
+}
@@ -0,0 +1,314 @@
+package middlleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/utils"
+	gcontext "github.com/gorilla/context"
+)
+
+//rateLimitCleanup is how often idle buckets are dropped from the store ...
+const rateLimitCleanup = time.Minute
+
+//RateLimitStore keeps the token buckets, keyed by rule and client ...
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit utils.RateLimit, now time.Time) (utils.RateLimitResult, error)
+	Cleanup(ctx context.Context, before time.Time) error
+}
+
+//MemoryRateLimitStore keeps the buckets of a single instance ...
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*utils.TokenBucket
+}
+
+//NewMemoryRateLimitStore ...
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*utils.TokenBucket)}
+}
+
+//Take ...
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit utils.RateLimit, now time.Time) (utils.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &utils.TokenBucket{}
+		s.buckets[key] = bucket
+	}
+
+	return bucket.Take(limit, now), nil
+}
+
+//Cleanup ...
+func (s *MemoryRateLimitStore) Cleanup(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, bucket := range s.buckets {
+		if bucket.Updated < before.UnixNano() {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+//DBRateLimitStore shares the buckets between instances through the rate_limit table ...
+type DBRateLimitStore struct{}
+
+//Take ...
+func (s DBRateLimitStore) Take(ctx context.Context, key string, limit utils.RateLimit, now time.Time) (utils.RateLimitResult, error) {
+	return model.TakeRateLimitToken(ctx, key, limit, now)
+}
+
+//Cleanup ...
+func (s DBRateLimitStore) Cleanup(ctx context.Context, before time.Time) error {
+	return model.DeleteIdleRateLimits(ctx, before)
+}
+
+//rateLimitRule is a parsed utils.RateLimitRule ...
+type rateLimitRule struct {
+	name      string
+	routes    []string
+	perUser   bool
+	limit     utils.RateLimit
+	userTypes map[string]utils.RateLimit
+}
+
+//matches compares whole path segments, so /todo does not cover /todos ...
+func (rule rateLimitRule) matches(path string) bool {
+	if len(rule.routes) == 0 {
+		return true
+	}
+
+	for _, route := range rule.routes {
+		if path == route || strings.HasPrefix(path, strings.TrimSuffix(route, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+//RateLimiter applies token bucket limits per route group, ByIP before JWT and ByUser after it ...
+type RateLimiter struct {
+	rules     []rateLimitRule
+	store     RateLimitStore
+	maxPeriod time.Duration
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+	cleanups    sync.WaitGroup
+}
+
+//NewRateLimiter checks the configured rules ...
+func NewRateLimiter(rules []utils.RateLimitRule, store RateLimitStore) (*RateLimiter, error) {
+	limiter := &RateLimiter{store: store, lastCleanup: time.Now()}
+
+	var errs []string
+
+	for i, rule := range rules {
+		parsed := rateLimitRule{
+			name:      rule.Name,
+			routes:    rule.Routes,
+			perUser:   rule.Per == "user",
+			userTypes: make(map[string]utils.RateLimit),
+		}
+
+		if parsed.name == "" {
+			parsed.name = strconv.Itoa(i)
+		}
+
+		errs = append(errs, rule.Problems(parsed.name)...)
+
+		period, _ := time.ParseDuration(rule.Period)
+
+		parsed.limit = utils.RateLimit{Burst: rule.Limit, Period: period}
+
+		for userType, limit := range rule.UserTypes {
+			parsed.userTypes[userType] = utils.RateLimit{Burst: limit, Period: period}
+		}
+
+		if period > limiter.maxPeriod {
+			limiter.maxPeriod = period
+		}
+
+		limiter.rules = append(limiter.rules, parsed)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+
+	return limiter, nil
+}
+
+//ByIP applies the first per-ip rule matching the path ...
+func (l *RateLimiter) ByIP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	l.limit(w, r, next, false)
+}
+
+//ByUser applies the first per-user rule matching the path, requests without a user count per ip ...
+func (l *RateLimiter) ByUser(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	l.limit(w, r, next, true)
+}
+
+func (l *RateLimiter) limit(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, perUser bool) {
+	user, _ := gcontext.Get(r, "user").(model.User)
+
+	result, limit, ok, err := l.take(utils.RequestContext(r), r.URL.Path, clientIP(r), user, perUser)
+	if err != nil {
+		//a broken store must not take the API down
+		utils.RequestLogger(r).WithError(err).Error("rate limit store")
+	}
+	if ok {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, seconds(limit.Period)))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			utils.WriteJSON(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	next(w, r)
+}
+
+//TakeByIP counts a call to path from ip against the first per-ip rule matching it, for callers that
+//aren't HTTP requests like the gRPC Login. A call no rule covers is allowed ...
+func (l *RateLimiter) TakeByIP(ctx context.Context, path, ip string) utils.RateLimitResult {
+	result, _, ok, err := l.take(ctx, path, ip, model.User{}, false)
+	if err != nil {
+		utils.Logger.WithError(err).Error("rate limit store")
+	}
+	if !ok {
+		return utils.RateLimitResult{Allowed: true}
+	}
+
+	return result
+}
+
+//take applies the first rule matching path, false when there is none or the store failed
+func (l *RateLimiter) take(ctx context.Context, path, ip string, user model.User, perUser bool) (utils.RateLimitResult, utils.RateLimit, bool, error) {
+	for _, rule := range l.rules {
+		if rule.perUser != perUser || !rule.matches(path) {
+			continue
+		}
+
+		limit := rule.limit
+		key := rule.name + ":ip:" + ip
+
+		if perUser && user.ID != 0 {
+			key = rule.name + ":user:" + strconv.Itoa(user.ID)
+			if typeLimit, ok := rule.userTypes[user.Type]; ok {
+				limit = typeLimit
+			}
+		}
+
+		now := time.Now()
+		l.cleanup(now)
+
+		result, err := l.store.Take(ctx, key, limit, now)
+
+		return result, limit, err == nil, err
+	}
+
+	return utils.RateLimitResult{}, utils.RateLimit{}, false, nil
+}
+
+//cleanup drops buckets idle for longer than any period, they are full again anyway ...
+func (l *RateLimiter) cleanup(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) < rateLimitCleanup {
+		return
+	}
+	l.lastCleanup = now
+
+	l.cleanups.Add(1)
+	go func() {
+		defer l.cleanups.Done()
+
+		if err := l.store.Cleanup(context.Background(), now.Add(-l.maxPeriod)); err != nil {
+			utils.Logger.WithError(err).Error("rate limit cleanup")
+		}
+	}()
+}
+
+//Close waits for a running cleanup, so the store can be closed after it ...
+func (l *RateLimiter) Close() {
+	l.cleanups.Wait()
+}
+
+//clientIP is the peer address, or the client a trusted proxy forwarded for ...
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return ForwardedClient(host, r.Header.Values("X-Forwarded-For"))
+}
+
+//ForwardedClient walks X-Forwarded-For back from peer while the hops are trusted proxies, the first
+//address that isn't one is the client, anything before it may be made up ...
+func ForwardedClient(peer string, forwardedFor []string) string {
+	if !trustedProxy(peer) {
+		return peer
+	}
+
+	var hops []string
+	for _, header := range forwardedFor {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		client = hops[i]
+		if !trustedProxy(client) {
+			break
+		}
+	}
+
+	return client
+}
+
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,62 @@
+package middlleware
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRateLimitRuleMatches(t *testing.T) {
+	tests := []struct {
+		routes []string
+		path   string
+		match  bool
+	}{
+		{nil, "/anything", true},
+		{[]string{"/login"}, "/login", true},
+		{[]string{"/login"}, "/login/2fa", true},
+		{[]string{"/login"}, "/loginx", false},
+		{[]string{"/todo"}, "/todos", false},
+		{[]string{"/todo"}, "/todo/5", true},
+		{[]string{"/todo/"}, "/todo/5", true},
+		{[]string{"/todo/"}, "/todos", false},
+		{[]string{"/password", "/email"}, "/email/verify", true},
+		{[]string{"/password", "/email"}, "/user/password", false},
+		{[]string{"/token/refresh"}, "/token", false},
+	}
+
+	for _, tt := range tests {
+		rule := rateLimitRule{routes: tt.routes}
+		if match := rule.matches(tt.path); match != tt.match {
+			t.Errorf("%v matches %q = %v, want %v", tt.routes, tt.path, match, tt.match)
+		}
+	}
+}
+
+func TestForwardedClient(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trustedProxies = []*net.IPNet{proxies}
+	defer func() { trustedProxies = nil }()
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		client    string
+	}{
+		{"untrusted peer keeps its address", "203.0.113.9", []string{"198.51.100.1"}, "203.0.113.9"},
+		{"trusted peer without header", "10.0.0.2", nil, "10.0.0.2"},
+		{"trusted peer forwards the client", "10.0.0.2", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hops before the client are ignored", "10.0.0.2", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2", []string{"198.51.100.1, 10.1.1.1", "10.2.2.2"}, "198.51.100.1"},
+		{"only proxies", "10.0.0.2", []string{"10.1.1.1"}, "10.1.1.1"},
+		{"garbage hop stops the walk", "10.0.0.2", []string{"198.51.100.1, unknown"}, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if client := ForwardedClient(tt.peer, tt.forwarded); client != tt.client {
+				t.Errorf("ForwardedClient = %s, want %s", client, tt.client)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package middlleware
+
+import (
+	"net/http"
+	"time"
+)
+
+//Streams lifts the server write timeout for long lived responses like /events and /ws,
+//it wraps the whole chain because negroni's writer hides the deadline from handlers ...
+func Streams(paths []string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		for _, path := range paths {
+			if req.URL.Path == path {
+				http.NewResponseController(res).SetWriteDeadline(time.Time{})
+				break
+			}
+		}
+
+		handler.ServeHTTP(res, req)
+	})
+}
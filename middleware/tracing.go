@@ -0,0 +1,86 @@
+package middlleware
+
+import (
+	"net/http"
+
+	"github.com/bicom/todos/utils"
+	"github.com/julienschmidt/httprouter"
+	"github.com/urfave/negroni"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Tracing starts the server span of a request and the span of its controller ...
+type Tracing struct {
+	Router *httprouter.Router
+}
+
+//Root continues the trace of an incoming traceparent header, it has to run before Clear
+//because it replaces the request and with it the key of the gorilla context ...
+func (t Tracing) Root(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	route := RoutePattern(t.Router, req)
+
+	ctx, span := utils.Tracer().Start(ctx, req.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", req.URL.Path),
+		))
+	defer span.End()
+
+	next(res, req.WithContext(ctx))
+
+	status := http.StatusOK
+	if rw, ok := res.(negroni.ResponseWriter); ok && rw.Status() != 0 {
+		status = rw.Status()
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+//Controller is the last middleware, its span covers the handler the router picks ...
+func (t Tracing) Controller(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	parent := utils.RequestContext(req)
+
+	ctx, span := utils.Tracer().Start(parent, "controller "+req.Method+" "+RoutePattern(t.Router, req))
+	defer span.End()
+
+	utils.SetRequestContext(req, ctx)
+	defer utils.SetRequestContext(req, parent)
+
+	next(res, req)
+}
+
+//Traced wraps a middleware in a span named after it, the span ends once the middleware calls next
+//so the rest of the chain doesn't count towards it ...
+func Traced(name string, handler negroni.HandlerFunc) negroni.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		parent := utils.RequestContext(req)
+
+		ctx, span := utils.Tracer().Start(parent, "middleware "+name)
+		utils.SetRequestContext(req, ctx)
+
+		ended := false
+		end := func() {
+			if !ended {
+				ended = true
+				span.End()
+				utils.SetRequestContext(req, parent)
+			}
+		}
+		defer end()
+
+		handler(res, req, func(w http.ResponseWriter, r *http.Request) {
+			end()
+			next(w, r)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateAPITokenTable keeps the personal access tokens, only by hash ...
+var CreateAPITokenTable = `CREATE TABLE api_token(
+	id INT(11) NOT NULL AUTO_INCREMENT,
+	userID INT(11) NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	tokenHash CHAR(64) NOT NULL,
+	scopes VARCHAR(255) NOT NULL,
+	created BIGINT NOT NULL,
+	expires BIGINT NOT NULL DEFAULT '0',
+	lastUsed BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(id),
+	UNIQUE KEY(tokenHash),
+	KEY(userID)
+	);
+	`
+
+//APITokenPrefix starts every personal access token, which tells them apart from JWTs ...
+const APITokenPrefix = "tdp_"
+
+//lastUsedEvery limits how often a busy token writes its lastUsed
+const lastUsedEvery = time.Minute
+
+//ErrAPITokenInvalid covers unknown and expired tokens alike ...
+var ErrAPITokenInvalid = errors.New("Invalid or expired API token")
+
+//scopeResources are the resources a route needs scopes for, by its first path segment.
+//Routes missing here can't be used with an API token
+var scopeResources = map[string][]string{
+	"/todo":   {"todos"},
+	"/todos":  {"todos"},
+	"/task":   {"tasks"},
+	"/tasks":  {"tasks"},
+	"/batch":  {"todos", "tasks"},
+	"/export": {"todos", "tasks"},
+	"/import": {"todos", "tasks"},
+	"/user":   {"users"},
+	"/users":  {"users"},
+}
+
+//credentialRoutes change how an account logs in, no scope reaches them and neither do the
+//sessions of /user/:id/sessions ...
+var credentialRoutes = []string{"/user/password1", "/user/password2", "/user/email"}
+
+//KnownScopes lists every scope a token can be given, write doesn't include read ...
+var KnownScopes = []string{"todos:read", "todos:write", "tasks:read", "tasks:write", "users:read", "users:write"}
+
+//Scopes is stored space separated and sent as a list ...
+type Scopes []string
+
+//Scan ...
+func (s *Scopes) Scan(src interface{}) error {
+	var value string
+
+	switch v := src.(type) {
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return errors.New("Scopes must be scanned from a string")
+	}
+
+	*s = strings.Fields(value)
+
+	return nil
+}
+
+//Value ...
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+//Has ...
+func (s Scopes) Has(scope string) bool {
+	for _, have := range s {
+		if have == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Allows tells if the scopes cover mode, read or write, on path ...
+func (s Scopes) Allows(path, mode string) bool {
+	for _, route := range credentialRoutes {
+		if path == route || strings.HasPrefix(path, route+"/") {
+			return false
+		}
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "sessions" {
+			return false
+		}
+	}
+
+	resources, ok := scopeResources["/"+strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]]
+	if !ok {
+		return false
+	}
+
+	for _, resource := range resources {
+		if !s.Has(resource + ":" + mode) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//APIToken is a long lived token a user creates for scripts, limited to its Scopes ...
+type APIToken struct {
+	ID        int    `db:"id" json:"id"`
+	UserID    int    `db:"userID" json:"userID"`
+	Name      string `db:"name" json:"name"`
+	TokenHash string `db:"tokenHash" json:"-"`
+	Scopes    Scopes `db:"scopes" json:"scopes"`
+	Created   int64  `db:"created" json:"created"`
+	Expires   int64  `db:"expires" json:"expires"` //0 never expires
+	LastUsed  int64  `db:"lastUsed" json:"lastUsed"`
+	Token     string `db:"-" json:"token,omitempty"` //only set when created
+}
+
+//CreateAPIToken stores the hash of token and sets the ID of t ...
+func CreateAPIToken(ctx context.Context, t *APIToken, token string) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CreateAPIToken")
+	defer done()
+
+	res, err := db.ExecContext(ctx, "INSERT INTO api_token (userID, name, tokenHash, scopes, created, expires) VALUES(?, ?, ?, ?, ?, ?)",
+		t.UserID, t.Name, HashToken(token), t.Scopes, t.Created, t.Expires)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = int(id)
+
+	return nil
+}
+
+//ListAPITokens ...
+func ListAPITokens(ctx context.Context, userID int) ([]APIToken, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListAPITokens")
+	defer done()
+
+	tokens := []APIToken{}
+
+	err := db.SelectContext(ctx, &tokens, "SELECT * FROM api_token WHERE userID=? ORDER BY created DESC", userID)
+
+	return tokens, err
+}
+
+//DeleteAPIToken revokes a token of userID, sql.ErrNoRows if there is none with id ...
+func DeleteAPIToken(ctx context.Context, userID, id int) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "DeleteAPIToken")
+	defer done()
+
+	res, err := db.ExecContext(ctx, "DELETE FROM api_token WHERE id=? AND userID=?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+
+	return nil
+}
+
+//CheckAPIToken returns ErrAPITokenInvalid once the token with id was deleted or expired ...
+func CheckAPIToken(ctx context.Context, id int, now time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CheckAPIToken")
+	defer done()
+
+	var count int
+
+	err := db.GetContext(ctx, &count, "SELECT COUNT(*) FROM api_token WHERE id=? AND (expires=0 OR expires > ?)", id, now.Unix())
+	if err == nil && count == 0 {
+		err = ErrAPITokenInvalid
+	}
+
+	return err
+}
+
+//UseAPIToken returns the token if it is known and unexpired, and records that it was used ...
+func UseAPIToken(ctx context.Context, token string, now time.Time) (APIToken, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "UseAPIToken")
+	defer done()
+
+	var t APIToken
+
+	err := db.GetContext(ctx, &t, "SELECT * FROM api_token WHERE tokenHash=?", HashToken(token))
+	if err == sql.ErrNoRows {
+		return t, ErrAPITokenInvalid
+	}
+	if err != nil {
+		return t, err
+	}
+
+	if t.Expires != 0 && t.Expires <= now.Unix() {
+		return t, ErrAPITokenInvalid
+	}
+
+	if now.Unix()-t.LastUsed >= int64(lastUsedEvery/time.Second) {
+		t.LastUsed = now.Unix()
+
+		_, err = db.ExecContext(ctx, "UPDATE api_token SET lastUsed=? WHERE id=?", t.LastUsed, t.ID)
+	}
+
+	return t, err
+}
@@ -0,0 +1,72 @@
+package model
+
+import "testing"
+
+func TestScopesAllows(t *testing.T) {
+	all := Scopes(KnownScopes)
+	readOnly := Scopes{"todos:read", "tasks:read", "users:read"}
+
+	tests := []struct {
+		name   string
+		scopes Scopes
+		path   string
+		mode   string
+		allow  bool
+	}{
+		{"todos read", Scopes{"todos:read"}, "/todos", "read", true},
+		{"todo by id", Scopes{"todos:read"}, "/todo/5", "read", true},
+		{"tasks of a list", Scopes{"tasks:write"}, "/task/5", "write", true},
+		{"users", Scopes{"users:read"}, "/user/5", "read", true},
+		{"other resource", Scopes{"todos:read"}, "/tasks/5", "read", false},
+		{"batch needs todos and tasks", Scopes{"todos:write"}, "/batch", "write", false},
+		{"batch with both", Scopes{"todos:write", "tasks:write"}, "/batch", "write", true},
+		{"export with both", readOnly, "/export", "read", true},
+
+		{"write is not read", Scopes{"todos:write"}, "/todos", "read", false},
+		{"read is not write", readOnly, "/todo/5", "write", false},
+		{"read only import", readOnly, "/import", "write", false},
+
+		{"password change", all, "/user/password1", "write", false},
+		{"password confirmation", all, "/user/password2", "write", false},
+		{"password below", all, "/user/password1/5", "write", false},
+		{"email change", all, "/user/email", "write", false},
+		{"email below", all, "/user/email/confirm", "write", false},
+		{"own account", all, "/me", "read", false},
+		{"own tokens", all, "/me/tokens", "write", false},
+		{"own 2fa", all, "/me/2fa/disable", "write", false},
+		{"sessions of a user", all, "/user/5/sessions", "read", false},
+		{"a session", all, "/user/5/sessions/9", "write", false},
+		{"sessions as a prefix only", all, "/user/5/sessionsx", "read", true},
+
+		{"unknown route", all, "/register", "write", false},
+		{"root", all, "/", "read", false},
+		{"no scopes", nil, "/todos", "read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allow := tt.scopes.Allows(tt.path, tt.mode); allow != tt.allow {
+				t.Errorf("%v Allows(%q, %s) = %v, want %v", tt.scopes, tt.path, tt.mode, allow, tt.allow)
+			}
+		})
+	}
+}
+
+func TestScopesScan(t *testing.T) {
+	var s Scopes
+	if err := s.Scan([]byte("todos:read  tasks:write")); err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 2 || !s.Has("todos:read") || !s.Has("tasks:write") {
+		t.Errorf("Scan = %v", s)
+	}
+
+	value, _ := s.Value()
+	if value != "todos:read tasks:write" {
+		t.Errorf("Value = %q", value)
+	}
+
+	if err := s.Scan(5); err == nil {
+		t.Error("Scan accepted a number")
+	}
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bicom/todos/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	//ErrBatchUnknownOp ...
+	ErrBatchUnknownOp = errors.New("Unknown batch operation")
+	//ErrBatchMissingField ...
+	ErrBatchMissingField = errors.New("Missing todoID, taskID or task name")
+	//ErrBatchNothingToUpdate ...
+	ErrBatchNothingToUpdate = errors.New("No task fields to update")
+	//ErrBatchSkipped is set on operations not run because an earlier one failed ...
+	ErrBatchSkipped = errors.New("Skipped because an earlier operation failed")
+)
+
+//BatchOp is one operation of a batch request ...
+type BatchOp struct {
+	Op     string    `json:"op"`     //create, update, delete or move
+	ToDoID int       `json:"todoID"` //list for create, target list for move
+	TaskID int       `json:"taskID"` //task for update, delete and move
+	Task   BatchTask `json:"task"`
+}
+
+//BatchTask holds task fields, nil fields are left unchanged on update ...
+type BatchTask struct {
+	Name        *string `json:"name"`
+	DateCreated *string `json:"dateCreated"`
+	DateFinish  *string `json:"dateFinish"`
+	Priority    *string `json:"priority"`
+	Status      *bool   `json:"status"`
+}
+
+//BatchResult is the outcome of one operation ...
+type BatchResult struct {
+	Index      int    `json:"index"`
+	Op         string `json:"op"`
+	Task       *Task  `json:"task,omitempty"`
+	FromToDoID int    `json:"fromTodoID,omitempty"` //previous list of a moved task
+	Err        error  `json:"-"`
+}
+
+//RunBatch executes ops in one transaction. In atomic mode the first failure rolls
+//everything back, otherwise only the failed operation is undone ...
+func RunBatch(ctx context.Context, user User, ops []BatchOp, atomic bool) ([]BatchResult, bool, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "RunBatch")
+	defer done()
+
+	results := make([]BatchResult, len(ops))
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return results, false, err
+	}
+
+	for i, op := range ops {
+		results[i].Index = i
+		results[i].Op = op.Op
+
+		savepoint := fmt.Sprintf("batch_op_%d", i)
+
+		if !atomic {
+			if _, err = tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+				tx.Rollback()
+				return results, false, err
+			}
+		}
+
+		results[i].Task, results[i].FromToDoID, results[i].Err = runBatchOp(ctx, tx, user, op)
+
+		if results[i].Err == nil {
+			if !atomic {
+				tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+			}
+			continue
+		}
+
+		if atomic {
+			tx.Rollback()
+
+			for j := i + 1; j < len(ops); j++ {
+				results[j] = BatchResult{Index: j, Op: ops[j].Op, Err: ErrBatchSkipped}
+			}
+
+			return results, false, nil
+		}
+
+		if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); err != nil {
+			tx.Rollback()
+			return results, false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return results, false, err
+	}
+
+	return results, true, nil
+}
+
+func runBatchOp(ctx context.Context, tx *sqlx.Tx, user User, op BatchOp) (*Task, int, error) {
+	switch op.Op {
+	case "create":
+		if op.ToDoID == 0 || op.Task.Name == nil {
+			return nil, 0, ErrBatchMissingField
+		}
+
+		if _, err := batchList(ctx, tx, user, op.ToDoID); err != nil {
+			return nil, 0, err
+		}
+
+		status := op.Task.Status != nil && *op.Task.Status
+
+		res, err := tx.ExecContext(ctx, insertTask,
+			*op.Task.Name, stringOr(op.Task.DateCreated, ""), stringOr(op.Task.DateFinish, ""), doneDate(status, ""), stringOr(op.Task.Priority, "0"), status, op.ToDoID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		task, err := batchTask(ctx, tx, user, int(id))
+
+		return task, 0, err
+
+	case "update":
+		task, err := batchTask(ctx, tx, user, op.TaskID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var set []string
+		var args []interface{}
+
+		if op.Task.Name != nil {
+			set, args = append(set, "name=?"), append(args, *op.Task.Name)
+		}
+		if op.Task.DateCreated != nil {
+			set, args = append(set, "dateC=?"), append(args, *op.Task.DateCreated)
+		}
+		if op.Task.DateFinish != nil {
+			set, args = append(set, "dateF=?"), append(args, *op.Task.DateFinish)
+		}
+		if op.Task.Priority != nil {
+			set, args = append(set, "priority=?"), append(args, *op.Task.Priority)
+		}
+		if op.Task.Status != nil {
+			set, args = append(set, "dateDone=IF(?, IF(status=1, dateDone, ?), '')"), append(args, *op.Task.Status, doneDate(true, ""))
+			set, args = append(set, "status=?"), append(args, *op.Task.Status)
+		}
+
+		if len(set) == 0 {
+			return nil, 0, ErrBatchNothingToUpdate
+		}
+
+		args = append(args, task.ID)
+
+		_, err = tx.ExecContext(ctx, "UPDATE task SET "+strings.Join(set, ", ")+" WHERE id=?", args...)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		task, err = batchTask(ctx, tx, user, task.ID)
+
+		return task, 0, err
+
+	case "delete":
+		task, err := batchTask(ctx, tx, user, op.TaskID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM task WHERE id=?", task.ID)
+
+		return task, 0, err
+
+	case "move":
+		if op.ToDoID == 0 {
+			return nil, 0, ErrBatchMissingField
+		}
+
+		task, err := batchTask(ctx, tx, user, op.TaskID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if _, err = batchList(ctx, tx, user, op.ToDoID); err != nil {
+			return nil, 0, err
+		}
+
+		from := task.ToDoID
+
+		_, err = tx.ExecContext(ctx, "UPDATE task SET ToDoID=? WHERE id=?", op.ToDoID, task.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		task.ToDoID = op.ToDoID
+
+		return task, from, nil
+	}
+
+	return nil, 0, ErrBatchUnknownOp
+}
+
+//batchList reads the list inside the transaction and applies the CheckToDoAccess rule ...
+func batchList(ctx context.Context, tx *sqlx.Tx, user User, todoID int) (ToDo, error) {
+	var todo ToDo
+
+	err := tx.GetContext(ctx, &todo, "SELECT * FROM ToDo WHERE id=?", todoID)
+	if err != nil {
+		return todo, err
+	}
+
+	if todo.UserID != user.ID && !user.IsAdmin() {
+		return todo, ErrListForbidden
+	}
+
+	return todo, nil
+}
+
+//batchTask reads the task inside the transaction, so tasks created earlier in the batch are visible ...
+func batchTask(ctx context.Context, tx *sqlx.Tx, user User, taskID int) (*Task, error) {
+	if taskID == 0 {
+		return nil, ErrBatchMissingField
+	}
+
+	var task Task
+
+	err := tx.GetContext(ctx, &task, "SELECT * FROM task WHERE id=?", taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = batchList(ctx, tx, user, task.ToDoID); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+//stringOr avoids NULL columns, which can't be scanned into Task ...
+func stringOr(s *string, def string) string {
+	if s == nil || *s == "" {
+		return def
+	}
+
+	return *s
+}
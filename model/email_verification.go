@@ -0,0 +1,119 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateEmailVerificationTable keeps the address a token verifies, a changed email only replaces
+//the old one once it is verified ...
+var CreateEmailVerificationTable = `CREATE TABLE email_verification(
+	tokenHash CHAR(64) NOT NULL,
+	userID INT(11) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	created BIGINT NOT NULL,
+	expires BIGINT NOT NULL,
+	used BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(tokenHash),
+	KEY(userID),
+	KEY(expires)
+	);
+	`
+
+//AddUserEmailVerified ...
+var AddUserEmailVerified = `ALTER TABLE users ADD COLUMN emailVerified BIGINT NOT NULL DEFAULT '0'`
+
+var (
+	//ErrVerificationTokenInvalid covers unknown, used and expired verification tokens alike ...
+	ErrVerificationTokenInvalid = errors.New("Invalid or expired verification token")
+	//ErrEmailTaken ...
+	ErrEmailTaken = errors.New("Email belongs to another account")
+)
+
+//CreateEmailVerification stores token verifying email for userID until expires. Earlier tokens of
+//the user stop working, so only the last mail counts ...
+func CreateEmailVerification(ctx context.Context, userID int, email, token string, now, expires time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CreateEmailVerification")
+	defer done()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM email_verification WHERE expires < ? OR (userID=? AND used=0)", now.Unix(), userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO email_verification (tokenHash, userID, email, created, expires) VALUES(?, ?, ?, ?, ?)",
+		HashToken(token), userID, email, now.Unix(), expires.Unix())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//VerifyEmail marks the address of token as verified, making it the email of the user if it was a change ...
+func VerifyEmail(ctx context.Context, token string, now time.Time) (User, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "VerifyEmail")
+	defer done()
+
+	var user User
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback()
+
+	var stored struct {
+		UserID int    `db:"userID"`
+		Email  string `db:"email"`
+	}
+
+	err = tx.GetContext(ctx, &stored, "SELECT userID, email FROM email_verification WHERE tokenHash=? AND used=0 AND expires > ? FOR UPDATE",
+		HashToken(token), now.Unix())
+	if err == sql.ErrNoRows {
+		return user, ErrVerificationTokenInvalid
+	}
+	if err != nil {
+		return user, err
+	}
+
+	//the address may have been taken since the change was asked for
+	var taken int
+
+	err = tx.GetContext(ctx, &taken, "SELECT COUNT(*) FROM users WHERE email=? AND id<>?", stored.Email, stored.UserID)
+	if err != nil {
+		return user, err
+	}
+	if taken > 0 {
+		return user, ErrEmailTaken
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email=?, emailVerified=? WHERE id=?", stored.Email, now.Unix(), stored.UserID)
+	if err != nil {
+		return user, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE email_verification SET used=? WHERE tokenHash=?", now.Unix(), HashToken(token))
+	if err != nil {
+		return user, err
+	}
+
+	err = tx.GetContext(ctx, &user, "SELECT * FROM users WHERE id=?", stored.UserID)
+	if err != nil {
+		return user, err
+	}
+
+	return user, tx.Commit()
+}
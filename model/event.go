@@ -0,0 +1,32 @@
+package model
+
+import (
+	"context"
+
+	"github.com/bicom/todos/utils"
+)
+
+//PublishToDoEvent sends a change of the given list to live subscribers ...
+func PublishToDoEvent(eventType string, todo ToDo, data interface{}) {
+	utils.Events.Publish(utils.Event{
+		Type:   eventType,
+		ToDoID: todo.ID,
+		UserID: todo.UserID,
+		Data:   data,
+	})
+}
+
+//PublishTaskEvent looks up the list owner and the current task row before publishing ...
+func PublishTaskEvent(ctx context.Context, eventType string, todoID int, taskID int) {
+	todo, err := GetAnyToDo(ctx, todoID)
+	if err != nil {
+		return
+	}
+
+	task, err := GetAnyTask(ctx, taskID)
+	if err != nil || task.ToDoID != todoID {
+		return
+	}
+
+	PublishToDoEvent(eventType, todo, task)
+}
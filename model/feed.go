@@ -0,0 +1,86 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateFeedTokenTable ...
+var CreateFeedTokenTable = `CREATE TABLE feed_token(
+	ToDoID INT(11) NOT NULL,
+	token VARCHAR(64) NOT NULL,
+	PRIMARY KEY(ToDoID),
+	UNIQUE KEY(token)
+	);
+	`
+
+//RenameFeedToken makes room for the hash in place of the token ...
+var RenameFeedToken = "ALTER TABLE feed_token CHANGE token tokenHash CHAR(64) NOT NULL"
+
+//HashFeedTokens hashes the tokens of feeds created before only hashes were kept, SHA2 gives the
+//same hex HashToken does ...
+var HashFeedTokens = "UPDATE feed_token SET tokenHash=SHA2(tokenHash, 256)"
+
+//DropOrphanFeedTokens removes the feeds of lists deleted before DeleteToDo took them along ...
+var DropOrphanFeedTokens = "DELETE feed_token FROM feed_token LEFT JOIN ToDo ON ToDo.id = feed_token.ToDoID WHERE ToDo.id IS NULL"
+
+//ErrFeedNotFound ...
+var ErrFeedNotFound = errors.New("Calendar feed not found")
+
+//FeedToken is the secret used by calendar clients to read a list without a JWT, only its hash is stored ...
+type FeedToken struct {
+	ToDoID int    `db:"ToDoID" json:"todoID"`
+	Token  string `db:"-" json:"token"` //only known when created
+}
+
+//NewFeedToken creates or rotates the feed token of a list ...
+func NewFeedToken(ctx context.Context, todoID int) (FeedToken, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "NewFeedToken")
+	defer done()
+
+	token, err := utils.RandomToken(24)
+	if err != nil {
+		return FeedToken{}, err
+	}
+
+	feed := FeedToken{ToDoID: todoID, Token: token}
+
+	_, err = db.ExecContext(ctx, "REPLACE INTO feed_token (ToDoID, tokenHash) VALUES(?, ?)", feed.ToDoID, HashToken(feed.Token))
+	if err != nil {
+		return FeedToken{}, err
+	}
+
+	return feed, nil
+}
+
+//DeleteFeedToken disables the calendar feed of a list ...
+func DeleteFeedToken(ctx context.Context, todoID int) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "DeleteFeedToken")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM feed_token WHERE ToDoID=?", todoID)
+
+	return err
+}
+
+//GetToDoByFeedToken ...
+func GetToDoByFeedToken(ctx context.Context, token string) (ToDo, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetToDoByFeedToken")
+	defer done()
+
+	var todo ToDo
+
+	err := db.GetContext(ctx, &todo, "SELECT ToDo.* FROM ToDo JOIN feed_token ON feed_token.ToDoID = ToDo.id WHERE feed_token.tokenHash=?", HashToken(token))
+
+	if err == sql.ErrNoRows {
+		return todo, ErrFeedNotFound
+	}
+
+	return todo, err
+}
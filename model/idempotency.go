@@ -0,0 +1,99 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateIdempotencyKeyTable ...
+var CreateIdempotencyKeyTable = `CREATE TABLE idempotency_key(
+	userID INT(11) NOT NULL,
+	idemKey VARCHAR(255) NOT NULL,
+	requestHash CHAR(64) NOT NULL,
+	status INT(11) NOT NULL DEFAULT '0',
+	contentType VARCHAR(255) NOT NULL DEFAULT '',
+	body MEDIUMBLOB,
+	created BIGINT NOT NULL,
+	PRIMARY KEY(userID, idemKey),
+	KEY(created)
+	);
+	`
+
+//IdempotentResponse is the stored answer to a request with an Idempotency-Key, Status is 0 while it runs ...
+type IdempotentResponse struct {
+	UserID      int    `db:"userID"`
+	Key         string `db:"idemKey"`
+	RequestHash string `db:"requestHash"`
+	Status      int    `db:"status"`
+	ContentType string `db:"contentType"`
+	Body        []byte `db:"body"`
+	Created     int64  `db:"created"`
+}
+
+//ReserveIdempotencyKey claims the key for a new request, or returns the earlier request that holds it ...
+func ReserveIdempotencyKey(ctx context.Context, userID int, key, requestHash string, ttl time.Duration) (*IdempotentResponse, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ReserveIdempotencyKey")
+	defer done()
+
+	now := time.Now()
+
+	//only this key, the rest of the expired rows are left to DeleteExpiredIdempotencyKeys
+	_, err := db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE userID=? AND idemKey=? AND created < ?",
+		userID, key, now.Add(-ttl).Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.ExecContext(ctx, "INSERT IGNORE INTO idempotency_key (userID, idemKey, requestHash, created) VALUES(?, ?, ?, ?)",
+		userID, key, requestHash, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 1 {
+		return nil, err
+	}
+
+	var stored IdempotentResponse
+
+	err = db.GetContext(ctx, &stored, "SELECT * FROM idempotency_key WHERE userID=? AND idemKey=?", userID, key)
+
+	return &stored, err
+}
+
+//SaveIdempotentResponse stores the response for replays of the key ...
+func SaveIdempotentResponse(ctx context.Context, userID int, key string, status int, contentType string, body []byte) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "SaveIdempotentResponse")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "UPDATE idempotency_key SET status=?, contentType=?, body=? WHERE userID=? AND idemKey=?",
+		status, contentType, body, userID, key)
+
+	return err
+}
+
+//ReleaseIdempotencyKey drops a reservation so the request can be retried ...
+func ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ReleaseIdempotencyKey")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE userID=? AND idemKey=?", userID, key)
+
+	return err
+}
+
+//DeleteExpiredIdempotencyKeys drops the keys reserved before the given time ...
+func DeleteExpiredIdempotencyKeys(ctx context.Context, before time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "DeleteExpiredIdempotencyKeys")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE created < ?", before.Unix())
+
+	return err
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+//markdownIndent marks one nesting level in task names, tasks have no subtasks ...
+const markdownIndent = "  "
+
+var markdownTask = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+\[([ xX])\](?:\s+(.*))?$`)
+
+//FormatMarkdown renders a list as a GitHub-style task list ...
+func FormatMarkdown(todo ToDo, tasks []Task) string {
+	var b strings.Builder
+
+	b.WriteString("# " + todo.Name + "\n\n")
+
+	if strings.TrimSpace(todo.Description) != "" {
+		b.WriteString(strings.TrimSpace(todo.Description) + "\n\n")
+	}
+
+	for _, task := range tasks {
+		level := 0
+		name := task.Name
+		for strings.HasPrefix(name, markdownIndent) {
+			level++
+			name = strings.TrimPrefix(name, markdownIndent)
+		}
+
+		check := " "
+		if task.Status {
+			check = "x"
+		}
+
+		b.WriteString(strings.Repeat(markdownIndent, level) + "- [" + check + "] " + strings.TrimSpace(name) + "\n")
+	}
+
+	return b.String()
+}
+
+//ParseMarkdown turns `- [ ]` and `- [x]` items into tasks. Nested items keep their
+//depth as indentation of the name, other lines are ignored ...
+func ParseMarkdown(text string) ([]Task, []LineError) {
+	var tasks []Task
+	var lineErrors []LineError
+
+	//indentation of the open parent items
+	var parents []int
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+
+	for line := 1; scanner.Scan(); line++ {
+		match := markdownTask.FindStringSubmatch(strings.Replace(scanner.Text(), "\t", "    ", -1))
+		if match == nil {
+			continue
+		}
+
+		indent := len(match[1])
+		for len(parents) > 0 && parents[len(parents)-1] >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		level := len(parents)
+		parents = append(parents, indent)
+
+		name := strings.TrimSpace(match[3])
+		if name == "" {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: "empty checklist item"})
+			continue
+		}
+
+		tasks = append(tasks, Task{
+			Name:     strings.Repeat(markdownIndent, level) + name,
+			Priority: "0",
+			Status:   match[2] != " ",
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		lineErrors = append(lineErrors, LineError{Line: 0, Error: err.Error()})
+	}
+
+	return tasks, lineErrors
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		tasks []Task
+		lines []int //of errors
+	}{
+		{
+			name:  "open and done",
+			text:  "- [ ] open\n- [x] done\n- [X] done too",
+			tasks: []Task{{Name: "open", Priority: "0"}, {Name: "done", Priority: "0", Status: true}, {Name: "done too", Priority: "0", Status: true}},
+		},
+		{
+			name:  "other bullets and numbers",
+			text:  "* [ ] star\n+ [ ] plus\n1. [ ] dot\n2) [x] paren",
+			tasks: []Task{{Name: "star", Priority: "0"}, {Name: "plus", Priority: "0"}, {Name: "dot", Priority: "0"}, {Name: "paren", Priority: "0", Status: true}},
+		},
+		{
+			name: "nesting",
+			text: "- [ ] parent\n  - [ ] child\n    - [x] grandchild\n  - [ ] second child\n- [ ] next parent",
+			tasks: []Task{
+				{Name: "parent", Priority: "0"},
+				{Name: "  child", Priority: "0"},
+				{Name: "    grandchild", Priority: "0", Status: true},
+				{Name: "  second child", Priority: "0"},
+				{Name: "next parent", Priority: "0"},
+			},
+		},
+		{
+			name:  "tabs indent",
+			text:  "- [ ] parent\n\t- [ ] child",
+			tasks: []Task{{Name: "parent", Priority: "0"}, {Name: "  child", Priority: "0"}},
+		},
+		{
+			name:  "other lines ignored",
+			text:  "# Title\n\nSome text\n- plain bullet\n- [ ] task",
+			tasks: []Task{{Name: "task", Priority: "0"}},
+		},
+		{
+			name:  "empty item",
+			text:  "- [ ]\n- [x]   \n- [ ] fine",
+			tasks: []Task{{Name: "fine", Priority: "0"}},
+			lines: []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, lineErrors := ParseMarkdown(tt.text)
+
+			if !reflect.DeepEqual(tasks, tt.tasks) {
+				t.Errorf("got %+v\nwant %+v", tasks, tt.tasks)
+			}
+
+			var lines []int
+			for _, e := range lineErrors {
+				lines = append(lines, e.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("errors on lines %v, want %v", lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	todo := ToDo{Name: "Trip", Description: " Pack before Friday "}
+	tasks := []Task{
+		{Name: "clothes", Priority: "0"},
+		{Name: "  socks", Priority: "0", Status: true},
+		{Name: "    wool ones", Priority: "0"},
+		{Name: "tickets", Priority: "0", Status: true},
+	}
+
+	text := FormatMarkdown(todo, tasks)
+
+	want := "# Trip\n\nPack before Friday\n\n- [ ] clothes\n  - [x] socks\n    - [ ] wool ones\n- [x] tickets\n"
+	if text != want {
+		t.Errorf("FormatMarkdown =\n%s\nwant\n%s", text, want)
+	}
+
+	parsed, lineErrors := ParseMarkdown(text)
+	if len(lineErrors) > 0 {
+		t.Fatalf("unexpected errors %+v", lineErrors)
+	}
+	if !reflect.DeepEqual(parsed, tasks) {
+		t.Errorf("round trip\ngot  %+v\nwant %+v", parsed, tasks)
+	}
+}
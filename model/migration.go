@@ -0,0 +1,117 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bicom/todos/utils"
+	"github.com/go-sql-driver/mysql"
+)
+
+//CreateSchemaMigrationTable ...
+var CreateSchemaMigrationTable = `CREATE TABLE IF NOT EXISTS schema_migrations(
+	version INT(11) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	applied BIGINT NOT NULL,
+	PRIMARY KEY(version)
+	);
+	`
+
+//Migration is a schema change, applied once and in Version order ...
+type Migration struct {
+	Version   int
+	Name      string
+	Statement string
+}
+
+//Migrations lists every schema change, new ones are appended with the next version ...
+var Migrations = []Migration{
+	{1, "create users", CreateUsersTable},
+	{2, "create ToDo", CreateToDoTable},
+	{3, "create task", CreateTaskTable},
+	{4, "create feed_token", CreateFeedTokenTable},
+	{5, "create idempotency_key", CreateIdempotencyKeyTable},
+	{6, "create rate_limit", CreateRateLimitTable},
+	{7, "create session", CreateSessionTable},
+	{8, "create refresh_token", CreateRefreshTokenTable},
+	{9, "create password_reset", CreatePasswordResetTable},
+	{10, "create email_verification", CreateEmailVerificationTable},
+	{11, "add users.emailVerified", AddUserEmailVerified},
+	{12, "mark existing users verified", "UPDATE users SET emailVerified=UNIX_TIMESTAMP() WHERE emailVerified=0"},
+	{13, "create totp", CreateTOTPTable},
+	{14, "create recovery_code", CreateRecoveryCodeTable},
+	{15, "create login_challenge", CreateLoginChallengeTable},
+	{16, "create api_token", CreateAPITokenTable},
+	{17, "add task.dateDone", AddTaskDateDone},
+	{18, "rename feed_token.token", RenameFeedToken},
+	{19, "hash feed tokens", HashFeedTokens},
+	{20, "drop feed tokens of deleted lists", DropOrphanFeedTokens},
+}
+
+//errTableExists and errColumnExists are returned by MySQL for changes the schema already has,
+//errNoSuchTable for schema_migrations before the first Migrate
+const (
+	errTableExists  = 1050
+	errColumnExists = 1060
+	errNoSuchTable  = 1146
+)
+
+//SchemaVersion returns the last applied migration and the version the code expects, it only reads
+//so readiness probes can call it ...
+func SchemaVersion(ctx context.Context) (int, int, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "SchemaVersion")
+	defer done()
+
+	latest := Migrations[len(Migrations)-1].Version
+
+	var current int
+
+	err := db.GetContext(ctx, &current, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == errNoSuchTable {
+		return 0, latest, nil
+	}
+
+	return current, latest, err
+}
+
+//Migrate applies the pending migrations, tables created before schema_migrations existed count as applied ...
+func Migrate(ctx context.Context) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Migrate")
+	defer done()
+
+	_, err := db.ExecContext(ctx, CreateSchemaMigrationTable)
+	if err != nil {
+		return err
+	}
+
+	current, _, err := SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range Migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		_, err = db.ExecContext(ctx, migration.Statement)
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && (mysqlErr.Number == errTableExists || mysqlErr.Number == errColumnExists) {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied) VALUES(?, ?, ?)",
+			migration.Version, migration.Name, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+
+		utils.Logger.WithField("version", migration.Version).Info("applied migration " + migration.Name)
+	}
+
+	return nil
+}
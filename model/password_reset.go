@@ -0,0 +1,96 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bicom/todos/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//CreatePasswordResetTable keeps hashes of the mailed tokens only ...
+var CreatePasswordResetTable = `CREATE TABLE password_reset(
+	tokenHash CHAR(64) NOT NULL,
+	userID INT(11) NOT NULL,
+	created BIGINT NOT NULL,
+	expires BIGINT NOT NULL,
+	used BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(tokenHash),
+	KEY(userID),
+	KEY(expires)
+	);
+	`
+
+//ErrResetTokenInvalid covers unknown, used and expired reset tokens alike ...
+var ErrResetTokenInvalid = errors.New("Invalid or expired reset token")
+
+//CreatePasswordReset stores token for userID until expires, and drops expired tokens ...
+func CreatePasswordReset(ctx context.Context, userID int, token string, now, expires time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CreatePasswordReset")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM password_reset WHERE expires < ?", now.Unix())
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO password_reset (tokenHash, userID, created, expires) VALUES(?, ?, ?, ?)",
+		HashToken(token), userID, now.Unix(), expires.Unix())
+
+	return err
+}
+
+//ResetPassword sets newpass for the owner of token, spends every reset token of the user and
+//revokes their sessions and API tokens, so whoever knew the old password is logged out ...
+func ResetPassword(ctx context.Context, token, newpass string, now time.Time) (int, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ResetPassword")
+	defer done()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+
+	err = tx.GetContext(ctx, &userID, "SELECT userID FROM password_reset WHERE tokenHash=? AND used=0 AND expires > ? FOR UPDATE",
+		HashToken(token), now.Unix())
+	if err == sql.ErrNoRows {
+		return 0, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	bytes, err := bcrypt.GenerateFromPassword([]byte(newpass), 12)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET password=? WHERE id=?", bytes, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE password_reset SET used=? WHERE userID=? AND used=0", now.Unix(), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE session SET revoked=? WHERE userID=? AND revoked=0", now.Unix(), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM api_token WHERE userID=?", userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
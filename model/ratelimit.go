@@ -0,0 +1,65 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateRateLimitTable ...
+var CreateRateLimitTable = `CREATE TABLE rate_limit(
+	bucket VARCHAR(255) NOT NULL,
+	tokens DOUBLE NOT NULL,
+	updated BIGINT NOT NULL,
+	PRIMARY KEY(bucket),
+	KEY(updated)
+	);
+	`
+
+//TakeRateLimitToken runs the token bucket of key under a row lock, so every instance sees the same bucket ...
+func TakeRateLimitToken(ctx context.Context, key string, limit utils.RateLimit, now time.Time) (utils.RateLimitResult, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "TakeRateLimitToken")
+	defer done()
+
+	var result utils.RateLimitResult
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT IGNORE INTO rate_limit (bucket, tokens, updated) VALUES(?, ?, ?)", key, limit.Burst, now.UnixNano())
+	if err != nil {
+		return result, err
+	}
+
+	var bucket utils.TokenBucket
+
+	err = tx.GetContext(ctx, &bucket, "SELECT tokens, updated FROM rate_limit WHERE bucket=? FOR UPDATE", key)
+	if err != nil {
+		return result, err
+	}
+
+	result = bucket.Take(limit, now)
+
+	_, err = tx.ExecContext(ctx, "UPDATE rate_limit SET tokens=?, updated=? WHERE bucket=?", bucket.Tokens, bucket.Updated, key)
+	if err != nil {
+		return result, err
+	}
+
+	return result, tx.Commit()
+}
+
+//DeleteIdleRateLimits drops buckets untouched since before, they would be full again ...
+func DeleteIdleRateLimits(ctx context.Context, before time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "DeleteIdleRateLimits")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM rate_limit WHERE updated < ?", before.UnixNano())
+
+	return err
+}
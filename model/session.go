@@ -0,0 +1,263 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateSessionTable ...
+var CreateSessionTable = `CREATE TABLE session(
+	id VARCHAR(64) NOT NULL,
+	userID INT(11) NOT NULL,
+	userAgent VARCHAR(255) NOT NULL DEFAULT '',
+	ip VARCHAR(64) NOT NULL DEFAULT '',
+	created BIGINT NOT NULL,
+	lastUsed BIGINT NOT NULL,
+	expires BIGINT NOT NULL,
+	revoked BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(id),
+	KEY(userID),
+	KEY(expires)
+	);
+	`
+
+//CreateRefreshTokenTable keeps every refresh token of a session, used ones stay to catch their reuse ...
+var CreateRefreshTokenTable = `CREATE TABLE refresh_token(
+	tokenHash CHAR(64) NOT NULL,
+	sessionID VARCHAR(64) NOT NULL,
+	created BIGINT NOT NULL,
+	used BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(tokenHash),
+	KEY(sessionID)
+	);
+	`
+
+var (
+	//ErrSessionEnded ...
+	ErrSessionEnded = errors.New("Session ended")
+	//ErrRefreshTokenInvalid ...
+	ErrRefreshTokenInvalid = errors.New("Invalid refresh token")
+	//ErrRefreshTokenReused means a rotated token came back, the session is revoked since either copy may be stolen ...
+	ErrRefreshTokenReused = errors.New("Refresh token reused, session revoked")
+)
+
+//Session is one login of a user, kept alive by rotating refresh tokens ...
+type Session struct {
+	ID        string `db:"id" json:"id"`
+	UserID    int    `db:"userID" json:"userID"`
+	UserAgent string `db:"userAgent" json:"userAgent"`
+	IP        string `db:"ip" json:"ip"`
+	Created   int64  `db:"created" json:"created"`
+	LastUsed  int64  `db:"lastUsed" json:"lastUsed"`
+	Expires   int64  `db:"expires" json:"expires"`
+	Revoked   int64  `db:"revoked" json:"-"`
+	Current   bool   `db:"-" json:"current"` //the session of the caller
+}
+
+//Active tells if the session can still be used at now ...
+func (s Session) Active(now time.Time) bool {
+	return s.Revoked == 0 && now.Unix() < s.Expires
+}
+
+//HashToken is what the tables store instead of the tokens and codes handed to users ...
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+//CreateSession stores the session with its first refresh token, and drops sessions that expired ...
+func CreateSession(ctx context.Context, session Session, refreshToken string) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CreateSession")
+	defer done()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE refresh_token FROM refresh_token JOIN session ON session.id = refresh_token.sessionID WHERE session.expires < ?", session.Created)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM session WHERE expires < ?", session.Created)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO session (id, userID, userAgent, ip, created, lastUsed, expires) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		session.ID, session.UserID, session.UserAgent, session.IP, session.Created, session.LastUsed, session.Expires)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO refresh_token (tokenHash, sessionID, created) VALUES(?, ?, ?)",
+		HashToken(refreshToken), session.ID, session.Created)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//GetSession ...
+func GetSession(ctx context.Context, id string) (Session, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetSession")
+	defer done()
+
+	var session Session
+
+	err := db.GetContext(ctx, &session, "SELECT * FROM session WHERE id=?", id)
+
+	return session, err
+}
+
+//CheckCredential tells if the session or the API token user came with still works, streams that
+//outlive a request call it to notice logouts, revocations and password resets ...
+func CheckCredential(ctx context.Context, user User, now time.Time) error {
+	if user.APITokenID != 0 {
+		return CheckAPIToken(ctx, user.APITokenID, now)
+	}
+
+	session, err := GetSession(ctx, user.SessionID)
+	if err == sql.ErrNoRows || err == nil && (session.UserID != user.ID || !session.Active(now)) {
+		return ErrSessionEnded
+	}
+
+	return err
+}
+
+//RotateRefreshToken swaps refreshToken for next and extends the session until expires,
+//a token that was already swapped revokes the whole session ...
+func RotateRefreshToken(ctx context.Context, refreshToken, next string, now time.Time, expires time.Time) (Session, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "RotateRefreshToken")
+	defer done()
+
+	var session Session
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return session, err
+	}
+	defer tx.Rollback()
+
+	var stored struct {
+		SessionID string `db:"sessionID"`
+		Used      int64  `db:"used"`
+	}
+
+	err = tx.GetContext(ctx, &stored, "SELECT sessionID, used FROM refresh_token WHERE tokenHash=? FOR UPDATE", HashToken(refreshToken))
+	if err == sql.ErrNoRows {
+		return session, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return session, err
+	}
+
+	err = tx.GetContext(ctx, &session, "SELECT * FROM session WHERE id=? FOR UPDATE", stored.SessionID)
+	if err == sql.ErrNoRows {
+		return session, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return session, err
+	}
+
+	if stored.Used != 0 {
+		_, err = tx.ExecContext(ctx, "UPDATE session SET revoked=? WHERE id=? AND revoked=0", now.Unix(), session.ID)
+		if err != nil {
+			return session, err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return session, err
+		}
+
+		return session, ErrRefreshTokenReused
+	}
+
+	if !session.Active(now) {
+		return session, ErrSessionEnded
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE refresh_token SET used=? WHERE tokenHash=?", now.Unix(), HashToken(refreshToken))
+	if err != nil {
+		return session, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO refresh_token (tokenHash, sessionID, created) VALUES(?, ?, ?)",
+		HashToken(next), session.ID, now.Unix())
+	if err != nil {
+		return session, err
+	}
+
+	session.LastUsed = now.Unix()
+	session.Expires = expires.Unix()
+
+	_, err = tx.ExecContext(ctx, "UPDATE session SET lastUsed=?, expires=? WHERE id=?", session.LastUsed, session.Expires, session.ID)
+	if err != nil {
+		return session, err
+	}
+
+	return session, tx.Commit()
+}
+
+//ListSessions returns the active sessions of userID, the most recently used first ...
+func ListSessions(ctx context.Context, userID int) ([]Session, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListSessions")
+	defer done()
+
+	sessions := []Session{}
+
+	err := db.SelectContext(ctx, &sessions, "SELECT * FROM session WHERE userID=? AND revoked=0 AND expires > ? ORDER BY lastUsed DESC",
+		userID, time.Now().Unix())
+
+	return sessions, err
+}
+
+//RevokeSession ends the session of userID, other sessions of the user stay, sql.ErrNoRows if it wasn't active ...
+func RevokeSession(ctx context.Context, userID int, id string) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "RevokeSession")
+	defer done()
+
+	res, err := db.ExecContext(ctx, "UPDATE session SET revoked=? WHERE id=? AND userID=? AND revoked=0", time.Now().Unix(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+
+	return nil
+}
+
+//RevokeOtherSessions ends every session of userID but keep, an empty keep ends them all ...
+func RevokeOtherSessions(ctx context.Context, userID int, keep string) (int64, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "RevokeOtherSessions")
+	defer done()
+
+	res, err := db.ExecContext(ctx, "UPDATE session SET revoked=? WHERE userID=? AND id<>? AND revoked=0", time.Now().Unix(), userID, keep)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -1,13 +1,22 @@
 package model
 
 import (
-	"fmt"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/bicom/todos/utils"
+	"github.com/jmoiron/sqlx"
 )
 
 var user User
 
+var (
+	//ErrListForbidden is returned for lists the user does not own ...
+	ErrListForbidden = errors.New("You are not allowed to make any changes to this list")
+)
+
 //ToDo ...
 type ToDo struct {
 	ID          int    `db:"id" json:"id"`                   //auto increment
@@ -22,6 +31,7 @@ type Task struct {
 	Name        string `db:"name" json:"name"` //task name (what is supposed to be done)
 	DateCreated string `db:"dateC" json:"dateCreated"`
 	DateFinish  string `db:"dateF" json:"dateFinish"`
+	DateDone    string `db:"dateDone" json:"dateDone"` //set when the task is completed, empty while open
 	Priority    string `db:"priority" json:"priority"` //value between 1-5
 	Status      bool   `db:"status" json:"status"`     //not completed, completed (0,1)
 	ToDoID      int    `db:"ToDoID" json:"todoID"`     //ID that is the same as ID from ToDo
@@ -50,69 +60,144 @@ var CreateTaskTable = `CREATE TABLE task(
 	);
 	`
 
+//AddTaskDateDone keeps when a task was completed, todo.txt exports it ...
+var AddTaskDateDone = `ALTER TABLE task ADD COLUMN dateDone VARCHAR(255) NOT NULL DEFAULT ''`
+
+//insertTask is shared by every path creating tasks
+const insertTask = "INSERT INTO task (name, dateC, dateF, dateDone, priority, status, ToDoID) VALUES(?,?,?,?,?,?,?)"
+
+//doneDate is the completion date stored for a task of status, date if one was given and today otherwise ...
+func doneDate(status bool, date string) string {
+	if !status {
+		return ""
+	}
+	if date != "" {
+		return date
+	}
+
+	return time.Now().Format("2006-01-02")
+}
+
 //CreateToDo ...
-func (td *ToDo) CreateToDo(userID int) error {
+func (td *ToDo) CreateToDo(ctx context.Context, userID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ToDo.CreateToDo")
+	defer done()
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec("INSERT INTO ToDo (name, description, userID) VALUES(?, ?, ?)", td.Name, td.Description, userID)
+	res, err := tx.ExecContext(ctx, "INSERT INTO ToDo (name, description, userID) VALUES(?, ?, ?)", td.Name, td.Description, userID)
 
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
-	return err
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	td.ID = int(id)
+	td.UserID = userID
+
+	return tx.Commit()
 }
 
 //CreateTask ...
-func (ts *Task) CreateTask(todoID int) error {
-	db := utils.SQLAcc.GetSQLDB()
+func (ts *Task) CreateTask(ctx context.Context, todoID int) error {
+	tasks := []Task{*ts}
 
-	tx, err := db.Begin()
+	err := CreateTasks(ctx, todoID, tasks)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec("INSERT INTO task (name, dateC, dateF, priority, status, ToDoID) VALUES(?,?,?,?,?,?)", ts.Name, ts.DateCreated, ts.DateFinish, ts.Priority, ts.Status, todoID)
+	*ts = tasks[0]
+
+	return nil
+}
+
+//CreateTasks adds all tasks to the list or none of them, and sets their IDs ...
+func CreateTasks(ctx context.Context, todoID int, tasks []Task) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.CreateTasks")
+	defer done()
 
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
+	defer tx.Rollback()
 
-	tx.Commit()
-	return err
-}
+	for i := range tasks {
+		ts := &tasks[i]
+		ts.DateDone = doneDate(ts.Status, ts.DateDone)
 
-//DeleteToDo ...
-func (td *ToDo) DeleteToDo(userID int, todoID int) error {
-	db := utils.SQLAcc.GetSQLDB()
-
-	if userID != 0 {
-		_, err := db.Exec("DELETE FROM ToDo WHERE id=? AND userID=?", todoID, userID)
+		res, err := tx.ExecContext(ctx, insertTask, ts.Name, ts.DateCreated, ts.DateFinish, ts.DateDone, ts.Priority, ts.Status, todoID)
 		if err != nil {
 			return err
 		}
-	} else {
-		_, err := db.Exec("DELETE FROM ToDo WHERE id=?", todoID)
+
+		id, err := res.LastInsertId()
 		if err != nil {
 			return err
 		}
+
+		ts.ID = int(id)
+		ts.ToDoID = todoID
 	}
-	return nil
+
+	return tx.Commit()
+}
+
+//DeleteToDo removes the list with its calendar feed ...
+func (td *ToDo) DeleteToDo(ctx context.Context, userID int, todoID int) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ToDo.DeleteToDo")
+	defer done()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var res sql.Result
+
+	if userID != 0 {
+		res, err = tx.ExecContext(ctx, "DELETE FROM ToDo WHERE id=? AND userID=?", todoID, userID)
+	} else {
+		res, err = tx.ExecContext(ctx, "DELETE FROM ToDo WHERE id=?", todoID)
+	}
+	if err != nil {
+		return err
+	}
+
+	//a list of someone else stays, and so does its feed
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM feed_token WHERE ToDoID=?", todoID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 //DeleteTask ...
-func (ts *Task) DeleteTask(todoID int, taskID int) error {
+func (ts *Task) DeleteTask(ctx context.Context, todoID int, taskID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.DeleteTask")
+	defer done()
 
-	_, err := db.Exec("DELETE FROM task WHERE id=? AND ToDoID=?", taskID, todoID)
+	_, err := db.ExecContext(ctx, "DELETE FROM task WHERE id=? AND ToDoID=?", taskID, todoID)
 	if err != nil {
 		return err
 	}
@@ -121,10 +206,12 @@ func (ts *Task) DeleteTask(todoID int, taskID int) error {
 }
 
 //UpdateToDoName ...
-func (td *ToDo) UpdateToDoName(todoID int) error {
+func (td *ToDo) UpdateToDoName(ctx context.Context, todoID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ToDo.UpdateToDoName")
+	defer done()
 
-	_, err := db.Exec("UPDATE ToDo SET name=? where id=?", td.Name, todoID)
+	_, err := db.ExecContext(ctx, "UPDATE ToDo SET name=? where id=?", td.Name, todoID)
 
 	if err != nil {
 		return err
@@ -134,10 +221,12 @@ func (td *ToDo) UpdateToDoName(todoID int) error {
 }
 
 //UpdateToDoDescription ...
-func (td *ToDo) UpdateToDoDescription(todoID int) error {
+func (td *ToDo) UpdateToDoDescription(ctx context.Context, todoID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ToDo.UpdateToDoDescription")
+	defer done()
 
-	_, err := db.Exec("UPDATE ToDo SET description=? where id=?", td.Description, todoID)
+	_, err := db.ExecContext(ctx, "UPDATE ToDo SET description=? where id=?", td.Description, todoID)
 
 	if err != nil {
 		return err
@@ -147,10 +236,12 @@ func (td *ToDo) UpdateToDoDescription(todoID int) error {
 }
 
 //UpdateTaskName ...
-func (ts *Task) UpdateTaskName(todoID int, taskID int) error {
+func (ts *Task) UpdateTaskName(ctx context.Context, todoID int, taskID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.UpdateTaskName")
+	defer done()
 
-	_, err := db.Exec("UPDATE task SET name=? WHERE id=? AND ToDoID=?", ts.Name, taskID, todoID)
+	_, err := db.ExecContext(ctx, "UPDATE task SET name=? WHERE id=? AND ToDoID=?", ts.Name, taskID, todoID)
 
 	if err != nil {
 		return err
@@ -160,10 +251,12 @@ func (ts *Task) UpdateTaskName(todoID int, taskID int) error {
 }
 
 //UpdateTaskDateStart ...
-func (ts Task) UpdateTaskDateStart(todoID int, taskID int) error {
+func (ts Task) UpdateTaskDateStart(ctx context.Context, todoID int, taskID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.UpdateTaskDateStart")
+	defer done()
 
-	_, err := db.Exec("UPDATE task SET dateC=? WHERE id=? AND ToDoID=?", ts.DateCreated, taskID, todoID)
+	_, err := db.ExecContext(ctx, "UPDATE task SET dateC=? WHERE id=? AND ToDoID=?", ts.DateCreated, taskID, todoID)
 	if err != nil {
 		return err
 	}
@@ -172,10 +265,12 @@ func (ts Task) UpdateTaskDateStart(todoID int, taskID int) error {
 }
 
 //UpdateTaskDateFinish ...
-func (ts *Task) UpdateTaskDateFinish(todoID int, taskID int) error {
+func (ts *Task) UpdateTaskDateFinish(ctx context.Context, todoID int, taskID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.UpdateTaskDateFinish")
+	defer done()
 
-	_, err := db.Exec("UPDATE task SET dateF=? WHERE id=? AND ToDoID=?", ts.DateFinish, taskID, todoID)
+	_, err := db.ExecContext(ctx, "UPDATE task SET dateF=? WHERE id=? AND ToDoID=?", ts.DateFinish, taskID, todoID)
 	if err != nil {
 		return err
 	}
@@ -184,10 +279,12 @@ func (ts *Task) UpdateTaskDateFinish(todoID int, taskID int) error {
 }
 
 //UpdateTaskPriority ...
-func (ts *Task) UpdateTaskPriority(todoID int, taskID int) error {
+func (ts *Task) UpdateTaskPriority(ctx context.Context, todoID int, taskID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.UpdateTaskPriority")
+	defer done()
 
-	_, err := db.Exec("UPDATE task SET priority=? WHERE id=? AND ToDoID=?", ts.Priority, taskID, todoID)
+	_, err := db.ExecContext(ctx, "UPDATE task SET priority=? WHERE id=? AND ToDoID=?", ts.Priority, taskID, todoID)
 	if err != nil {
 		return err
 	}
@@ -196,10 +293,14 @@ func (ts *Task) UpdateTaskPriority(todoID int, taskID int) error {
 }
 
 //UpdateTaskStatus ...
-func (ts *Task) UpdateTaskStatus(todoID int, taskID int) error {
+func (ts *Task) UpdateTaskStatus(ctx context.Context, todoID int, taskID int) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "Task.UpdateTaskStatus")
+	defer done()
 
-	_, err := db.Exec("UPDATE task SET status=? WHERE id=? AND ToDoID=?", ts.Status, taskID, todoID)
+	//dateDone is set before status, so it still sees whether the task was completed already
+	_, err := db.ExecContext(ctx, "UPDATE task SET dateDone=IF(?, IF(status=1, dateDone, ?), ''), status=? WHERE id=? AND ToDoID=?",
+		ts.Status, doneDate(true, ""), ts.Status, taskID, todoID)
 	if err != nil {
 		return err
 	}
@@ -208,24 +309,26 @@ func (ts *Task) UpdateTaskStatus(todoID int, taskID int) error {
 }
 
 //ListAllToDos (admin only)...
-func ListAllToDos(userID int) ([]ToDo, error) {
+func ListAllToDos(ctx context.Context, userID int) ([]ToDo, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListAllToDos")
+	defer done()
 
 	var todos []ToDo
 	var err error
 
 	if userID == 0 {
-		err = db.Select(&todos, "SELECT * FROM ToDo")
+		err = db.SelectContext(ctx, &todos, "SELECT * FROM ToDo")
 
 		if err != nil {
-			fmt.Println("Cannot show all created ToDos")
+			utils.Logger.WithError(err).Error("Cannot show all created ToDos")
 			return nil, err
 		}
 	} else {
-		err = db.Select(&todos, "SELECT * FROM ToDo WHERE userID=?", userID)
+		err = db.SelectContext(ctx, &todos, "SELECT * FROM ToDo WHERE userID=?", userID)
 
 		if err != nil {
-			fmt.Println("Cannot show all created ToDos")
+			utils.Logger.WithError(err).WithField("user_id", userID).Error("Cannot show all created ToDos")
 			return nil, err
 		}
 	}
@@ -234,27 +337,57 @@ func ListAllToDos(userID int) ([]ToDo, error) {
 }
 
 //GetAnyToDo returns ToDo using ToDoID ...
-func GetAnyToDo(todoID int) (ToDo, error) {
+func GetAnyToDo(ctx context.Context, todoID int) (ToDo, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetAnyToDo")
+	defer done()
 
 	var todo ToDo
 
-	err := db.Get(&todo, "SELECT * FROM ToDo WHERE id=?", todoID)
+	err := db.GetContext(ctx, &todo, "SELECT * FROM ToDo WHERE id=?", todoID)
+	if err != nil {
+		return todo, err
+	}
+
+	return todo, nil
+
+}
+
+//CheckToDoAccess returns the list if the user owns it or is admin ...
+func CheckToDoAccess(ctx context.Context, user User, todoID int) (ToDo, error) {
+	todo, err := GetAnyToDo(ctx, todoID)
 	if err != nil {
 		return todo, err
 	}
 
+	if todo.UserID != user.ID && !user.IsAdmin() {
+		return todo, ErrListForbidden
+	}
+
 	return todo, nil
+}
 
+//CheckTaskAccess returns the task and its list if the user may change them ...
+func CheckTaskAccess(ctx context.Context, user User, taskID int) (Task, ToDo, error) {
+	task, err := GetAnyTask(ctx, taskID)
+	if err != nil {
+		return task, ToDo{}, err
+	}
+
+	todo, err := CheckToDoAccess(ctx, user, task.ToDoID)
+
+	return task, todo, err
 }
 
 //GetAnyTask returns Task using taskID ...
-func GetAnyTask(taskID int) (Task, error) {
+func GetAnyTask(ctx context.Context, taskID int) (Task, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetAnyTask")
+	defer done()
 
 	var task Task
 
-	err := db.Get(&task, "SELECT * FROM task WHERE id=?", taskID)
+	err := db.GetContext(ctx, &task, "SELECT * FROM task WHERE id=?", taskID)
 	if err != nil {
 		return task, err
 	}
@@ -264,13 +397,15 @@ func GetAnyTask(taskID int) (Task, error) {
 }
 
 //ListTasks shows all tasks per a user ...
-func ListTasks(tdid int) ([]Task, error) {
+func ListTasks(ctx context.Context, tdid int) ([]Task, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListTasks")
+	defer done()
 
 	var tasks []Task
 	var err error
 
-	err = db.Select(&tasks, "SELECT * FROM task WHERE ToDoID=?", tdid)
+	err = db.SelectContext(ctx, &tasks, "SELECT * FROM task WHERE ToDoID=?", tdid)
 
 	if err != nil {
 		return tasks, err
@@ -279,13 +414,90 @@ func ListTasks(tdid int) ([]Task, error) {
 	return tasks, nil
 }
 
+//ListTasksForToDos returns the tasks of several lists with one query ...
+func ListTasksForToDos(ctx context.Context, todoIDs []int) ([]Task, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListTasksForToDos")
+	defer done()
+
+	var tasks []Task
+
+	if len(todoIDs) == 0 {
+		return tasks, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM task WHERE ToDoID IN (?)", todoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.SelectContext(ctx, &tasks, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+//GetToDos returns several lists by ID with one query ...
+func GetToDos(ctx context.Context, todoIDs []int) ([]ToDo, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetToDos")
+	defer done()
+
+	var todos []ToDo
+
+	if len(todoIDs) == 0 {
+		return todos, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM ToDo WHERE id IN (?)", todoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.SelectContext(ctx, &todos, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+//ListToDosForUsers returns the lists of several users with one query ...
+func ListToDosForUsers(ctx context.Context, userIDs []int) ([]ToDo, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListToDosForUsers")
+	defer done()
+
+	var todos []ToDo
+
+	if len(userIDs) == 0 {
+		return todos, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM ToDo WHERE userID IN (?)", userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.SelectContext(ctx, &todos, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
 //ListAllActiveTasks ...
-func ListAllActiveTasks(tdid int) ([]Task, error) {
+func ListAllActiveTasks(ctx context.Context, tdid int) ([]Task, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListAllActiveTasks")
+	defer done()
 
 	var activeTasks []Task
 
-	err := db.Select(&activeTasks, "SELECT * FROM task WHERE status=0 AND ToDoID=?", tdid)
+	err := db.SelectContext(ctx, &activeTasks, "SELECT * FROM task WHERE status=0 AND ToDoID=?", tdid)
 	if err != nil {
 		return nil, err
 	}
@@ -294,12 +506,14 @@ func ListAllActiveTasks(tdid int) ([]Task, error) {
 }
 
 //ListCompletedTasks ...
-func ListCompletedTasks(tdid string) ([]Task, error) {
+func ListCompletedTasks(ctx context.Context, tdid string) ([]Task, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListCompletedTasks")
+	defer done()
 
 	var completedTasks []Task
 
-	err := db.Select(&completedTasks, "SELECT * FROM task WHERE status=1 AND ToDoID=?", tdid)
+	err := db.SelectContext(ctx, &completedTasks, "SELECT * FROM task WHERE status=1 AND ToDoID=?", tdid)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,212 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bicom/todos/utils"
+)
+
+//LineError is a parse error of one line of an imported text file ...
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+var (
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtSpaces   = regexp.MustCompile(`\s+`)
+)
+
+//TodoTxtProject is the +project token used for a list ...
+func TodoTxtProject(todo ToDo) string {
+	return "+" + todoTxtSpaces.ReplaceAllString(strings.TrimSpace(todo.Name), "_")
+}
+
+//FormatTodoTxt renders the tasks of a list in todo.txt syntax. Priority 1-5 becomes
+//(A)-(E), DateFinish becomes due: and completed tasks start with x and their completion
+//and creation dates, keeping their priority as pri: ...
+func FormatTodoTxt(todo ToDo, tasks []Task) string {
+	var b strings.Builder
+
+	project := TodoTxtProject(todo)
+
+	for _, task := range tasks {
+		var parts []string
+
+		priority := todoTxtLetter(task.Priority)
+
+		if task.Status {
+			parts = append(parts, "x")
+		} else if priority != "" {
+			parts = append(parts, "("+priority+")")
+		}
+
+		//a creation date after x only reads as one behind a completion date, tasks completed
+		//before completion dates were kept have neither
+		done, _, hasDone := utils.ParseDate(task.DateDone)
+		if task.Status && hasDone {
+			parts = append(parts, done.Format("2006-01-02"))
+		}
+
+		if created, _, ok := utils.ParseDate(task.DateCreated); ok && (!task.Status || hasDone) {
+			parts = append(parts, created.Format("2006-01-02"))
+		}
+
+		parts = append(parts, strings.Join(strings.Fields(task.Name), " "))
+
+		if !strings.Contains(" "+task.Name+" ", " "+project+" ") {
+			parts = append(parts, project)
+		}
+
+		if due, _, ok := utils.ParseDate(task.DateFinish); ok {
+			parts = append(parts, "due:"+due.Format("2006-01-02"))
+		}
+
+		if task.Status && priority != "" {
+			parts = append(parts, "pri:"+priority)
+		}
+
+		b.WriteString(strings.Join(parts, " "))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+//ParseTodoTxt parses todo.txt lines into tasks of the list, the list's own +project
+//token is dropped and other +project and @context tokens stay in the name ...
+func ParseTodoTxt(todo ToDo, text string) ([]Task, []LineError) {
+	var tasks []Task
+	var lineErrors []LineError
+
+	project := TodoTxtProject(todo)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		task, err := parseTodoTxtLine(scanner.Text(), project)
+		if err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	if err := scanner.Err(); err != nil {
+		lineErrors = append(lineErrors, LineError{Line: 0, Error: err.Error()})
+	}
+
+	return tasks, lineErrors
+}
+
+func parseTodoTxtLine(line string, project string) (Task, error) {
+	var task Task
+
+	fields := strings.Fields(line)
+
+	if len(fields) > 0 && fields[0] == "x" {
+		task.Status = true
+		fields = fields[1:]
+
+		//x COMPLETION [CREATION]
+		var dates []string
+		for len(fields) > 0 && len(dates) < 2 && todoTxtDate.MatchString(fields[0]) {
+			dates = append(dates, fields[0])
+			fields = fields[1:]
+		}
+		if len(dates) > 0 {
+			task.DateDone = dates[0]
+		}
+		if len(dates) == 2 {
+			task.DateCreated = dates[1]
+		}
+	} else {
+		if len(fields) > 0 && todoTxtPriority.MatchString(fields[0]) {
+			priority, err := todoTxtPriorityValue(todoTxtPriority.FindStringSubmatch(fields[0])[1])
+			if err != nil {
+				return task, err
+			}
+			task.Priority = priority
+			fields = fields[1:]
+		}
+
+		if len(fields) > 0 && todoTxtDate.MatchString(fields[0]) {
+			task.DateCreated = fields[0]
+			fields = fields[1:]
+		}
+	}
+
+	var words []string
+
+	for _, field := range fields {
+		switch {
+		case field == project:
+			continue
+		case strings.HasPrefix(field, "due:"):
+			due := strings.TrimPrefix(field, "due:")
+			if !todoTxtDate.MatchString(due) {
+				return task, fmt.Errorf("invalid due date %q", due)
+			}
+			task.DateFinish = due
+		case strings.HasPrefix(field, "pri:"):
+			priority, err := todoTxtPriorityValue(strings.TrimPrefix(field, "pri:"))
+			if err != nil {
+				return task, err
+			}
+			task.Priority = priority
+		default:
+			words = append(words, field)
+		}
+	}
+
+	if task.DateCreated != "" {
+		if _, _, ok := utils.ParseDate(task.DateCreated); !ok {
+			return task, fmt.Errorf("invalid creation date %q", task.DateCreated)
+		}
+	}
+
+	if task.DateDone != "" {
+		if _, _, ok := utils.ParseDate(task.DateDone); !ok {
+			return task, fmt.Errorf("invalid completion date %q", task.DateDone)
+		}
+	}
+
+	task.Name = strings.Join(words, " ")
+
+	if task.Name == "" {
+		return task, fmt.Errorf("missing task description")
+	}
+
+	if task.Priority == "" {
+		task.Priority = "0"
+	}
+
+	return task, nil
+}
+
+func todoTxtLetter(priority string) string {
+	p, err := strconv.Atoi(strings.TrimSpace(priority))
+	if err != nil || p < 1 || p > 5 {
+		return ""
+	}
+
+	return string(rune('A' + p - 1))
+}
+
+func todoTxtPriorityValue(letter string) (string, error) {
+	if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'E' {
+		return "", fmt.Errorf("priority (%s) is out of range, only (A)-(E) are supported", letter)
+	}
+
+	return strconv.Itoa(int(letter[0]-'A') + 1), nil
+}
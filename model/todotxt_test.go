@@ -0,0 +1,155 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+var todoTxtList = ToDo{ID: 7, Name: "Home chores"}
+
+func TestParseTodoTxt(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Task
+	}{
+		{
+			name: "plain",
+			line: "water the plants",
+			want: Task{Name: "water the plants", Priority: "0"},
+		},
+		{
+			name: "priority and creation date",
+			line: "(B) 2024-03-01 call the plumber",
+			want: Task{Name: "call the plumber", Priority: "2", DateCreated: "2024-03-01"},
+		},
+		{
+			name: "due date",
+			line: "pay rent due:2024-04-01",
+			want: Task{Name: "pay rent", Priority: "0", DateFinish: "2024-04-01"},
+		},
+		{
+			name: "own project dropped, others kept",
+			line: "(A) fix the sink +Home_chores +Plumbing @home",
+			want: Task{Name: "fix the sink +Plumbing @home", Priority: "1"},
+		},
+		{
+			name: "completed with both dates and pri",
+			line: "x 2024-03-05 2024-03-01 buy milk pri:C",
+			want: Task{Name: "buy milk", Priority: "3", Status: true, DateDone: "2024-03-05", DateCreated: "2024-03-01"},
+		},
+		{
+			name: "completed with completion date only",
+			line: "x 2024-03-05 buy bread",
+			want: Task{Name: "buy bread", Priority: "0", Status: true, DateDone: "2024-03-05"},
+		},
+		{
+			name: "completed without dates",
+			line: "x take out the trash",
+			want: Task{Name: "take out the trash", Priority: "0", Status: true},
+		},
+		{
+			name: "priority after x is part of the name",
+			line: "x (A) sweep",
+			want: Task{Name: "(A) sweep", Priority: "0", Status: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, lineErrors := ParseTodoTxt(todoTxtList, tt.line)
+			if len(lineErrors) > 0 {
+				t.Fatalf("unexpected errors %+v", lineErrors)
+			}
+			if len(tasks) != 1 {
+				t.Fatalf("got %d tasks, want 1", len(tasks))
+			}
+			if !reflect.DeepEqual(tasks[0], tt.want) {
+				t.Errorf("got %+v\nwant %+v", tasks[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTodoTxtErrors(t *testing.T) {
+	text := "ok task\n\n(F) out of range\ndo it due:tomorrow\nx 2024-13-45 2024-01-01 bad completion\n+Home_chores\npri:Z odd"
+
+	tasks, lineErrors := ParseTodoTxt(todoTxtList, text)
+
+	if len(tasks) != 1 || tasks[0].Name != "ok task" {
+		t.Errorf("got tasks %+v, want only ok task", tasks)
+	}
+
+	var lines []int
+	for _, e := range lineErrors {
+		lines = append(lines, e.Line)
+	}
+
+	want := []int{3, 4, 5, 6, 7}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("errors on lines %v, want %v (%+v)", lines, want, lineErrors)
+	}
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	tasks := []Task{
+		{Name: "open with everything +Plumbing @home", Priority: "2", DateCreated: "2024-03-01", DateFinish: "2024-03-10"},
+		{Name: "open and plain", Priority: "0"},
+		{Name: "done with dates", Priority: "1", Status: true, DateCreated: "2024-02-01", DateDone: "2024-02-03"},
+		{Name: "done without creation", Priority: "0", Status: true, DateDone: "2024-02-03", DateFinish: "2024-02-05"},
+	}
+
+	text := FormatTodoTxt(todoTxtList, tasks)
+
+	want := "(B) 2024-03-01 open with everything +Plumbing @home +Home_chores due:2024-03-10\n" +
+		"open and plain +Home_chores\n" +
+		"x 2024-02-03 2024-02-01 done with dates +Home_chores pri:A\n" +
+		"x 2024-02-03 done without creation +Home_chores due:2024-02-05\n"
+	if text != want {
+		t.Errorf("FormatTodoTxt =\n%s\nwant\n%s", text, want)
+	}
+
+	parsed, lineErrors := ParseTodoTxt(todoTxtList, text)
+	if len(lineErrors) > 0 {
+		t.Fatalf("unexpected errors %+v", lineErrors)
+	}
+	if !reflect.DeepEqual(parsed, tasks) {
+		t.Errorf("round trip\ngot  %+v\nwant %+v", parsed, tasks)
+	}
+}
+
+func TestFormatTodoTxtUnknownCompletion(t *testing.T) {
+	//tasks completed before completion dates were kept can't show their creation date either
+	tasks := []Task{{Name: "old", Priority: "0", Status: true, DateCreated: "2024-01-01"}}
+
+	if text := FormatTodoTxt(todoTxtList, tasks); text != "x old +Home_chores\n" {
+		t.Errorf("FormatTodoTxt = %q", text)
+	}
+}
+
+func TestTodoTxtPriority(t *testing.T) {
+	tests := []struct {
+		priority string
+		letter   string
+	}{
+		{"1", "A"},
+		{"5", "E"},
+		{"0", ""},
+		{"6", ""},
+		{"", ""},
+		{"high", ""},
+	}
+
+	for _, tt := range tests {
+		if letter := todoTxtLetter(tt.priority); letter != tt.letter {
+			t.Errorf("todoTxtLetter(%q) = %q, want %q", tt.priority, letter, tt.letter)
+		}
+
+		if tt.letter == "" {
+			continue
+		}
+		if value, err := todoTxtPriorityValue(tt.letter); err != nil || value != tt.priority {
+			t.Errorf("todoTxtPriorityValue(%q) = %q, %v", tt.letter, value, err)
+		}
+	}
+}
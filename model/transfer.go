@@ -0,0 +1,214 @@
+package model
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bicom/todos/utils"
+)
+
+//ExportList is a ToDo list together with its tasks ...
+type ExportList struct {
+	ToDo
+	Tasks []Task `json:"tasks"`
+}
+
+//ImportedList reports what happened to one imported list ...
+type ImportedList struct {
+	OldID  int    `json:"oldID"`
+	NewID  int    `json:"newID"` //0 on dry run
+	Name   string `json:"name"`
+	Status string `json:"status"` //created or merged (a list with that name exists)
+}
+
+//ImportedTask reports what happened to one imported task ...
+type ImportedTask struct {
+	OldID  int    `json:"oldID"`
+	NewID  int    `json:"newID"` //0 on dry run or for duplicates
+	ToDoID int    `json:"todoID"`
+	Name   string `json:"name"`
+	Status string `json:"status"` //created or duplicate
+}
+
+//ImportReport ...
+type ImportReport struct {
+	DryRun       bool           `json:"dryRun"`
+	Lists        []ImportedList `json:"lists"`
+	Tasks        []ImportedTask `json:"tasks"`
+	CreatedLists int            `json:"createdLists"`
+	CreatedTasks int            `json:"createdTasks"`
+	MergedLists  int            `json:"mergedLists"`
+	SkippedTasks int            `json:"skippedTasks"`
+}
+
+//exportBatch is how many lists share one query for their tasks
+const exportBatch = 100
+
+//ExportLists reads every list of the user with its tasks and hands them to each one by one,
+//so callers can stream them. Tasks are read with one query per exportBatch lists ...
+func ExportLists(ctx context.Context, userID int, each func(ExportList) error) error {
+	todos, err := ListAllToDos(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(todos); start += exportBatch {
+		end := start + exportBatch
+		if end > len(todos) {
+			end = len(todos)
+		}
+		batch := todos[start:end]
+
+		ids := make([]int, len(batch))
+		for i, todo := range batch {
+			ids[i] = todo.ID
+		}
+
+		tasks, err := ListTasksForToDos(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		byList := make(map[int][]Task)
+		for _, task := range tasks {
+			byList[task.ToDoID] = append(byList[task.ToDoID], task)
+		}
+
+		for _, todo := range batch {
+			list := ExportList{ToDo: todo, Tasks: byList[todo.ID]}
+			if list.Tasks == nil {
+				list.Tasks = []Task{}
+			}
+
+			err = each(list)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//ImportLists creates the lists and tasks for the user in one transaction. Lists are
+//matched to existing ones by name and merged, tasks with a name already present in
+//the list are skipped. A dry run reports the same without writing anything ...
+func ImportLists(ctx context.Context, userID int, lists []ExportList, dryRun bool) (ImportReport, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ImportLists")
+	defer done()
+
+	report := ImportReport{DryRun: dryRun, Lists: []ImportedList{}, Tasks: []ImportedTask{}}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	var existing []ToDo
+
+	err = tx.SelectContext(ctx, &existing, "SELECT * FROM ToDo WHERE userID=?", userID)
+	if err != nil {
+		return report, err
+	}
+
+	byName := make(map[string]int)
+	for _, todo := range existing {
+		byName[importKey(todo.Name)] = todo.ID
+	}
+
+	//task names per list name, lists created on a dry run have no ID to look them up by
+	taskNames := make(map[string]map[string]bool)
+
+	for _, list := range lists {
+		imported := ImportedList{OldID: list.ID, Name: list.Name, Status: "merged"}
+		key := importKey(list.Name)
+
+		todoID, ok := byName[key]
+		if !ok {
+			if !dryRun {
+				res, err := tx.ExecContext(ctx, "INSERT INTO ToDo (name, description, userID) VALUES(?, ?, ?)", list.Name, list.Description, userID)
+				if err != nil {
+					return report, err
+				}
+
+				id, err := res.LastInsertId()
+				if err != nil {
+					return report, err
+				}
+
+				todoID = int(id)
+			}
+
+			byName[key] = todoID
+			taskNames[key] = make(map[string]bool)
+			imported.Status = "created"
+			report.CreatedLists++
+		} else {
+			report.MergedLists++
+		}
+
+		imported.NewID = todoID
+		report.Lists = append(report.Lists, imported)
+
+		names, ok := taskNames[key]
+		if !ok {
+			var stored []string
+
+			err = tx.SelectContext(ctx, &stored, "SELECT name FROM task WHERE ToDoID=?", todoID)
+			if err != nil {
+				return report, err
+			}
+
+			names = make(map[string]bool)
+			for _, name := range stored {
+				names[importKey(name)] = true
+			}
+			taskNames[key] = names
+		}
+
+		for _, task := range list.Tasks {
+			importedTask := ImportedTask{OldID: task.ID, ToDoID: todoID, Name: task.Name, Status: "duplicate"}
+
+			if names[importKey(task.Name)] {
+				report.SkippedTasks++
+				report.Tasks = append(report.Tasks, importedTask)
+				continue
+			}
+
+			if task.Priority == "" {
+				task.Priority = "0"
+			}
+
+			if !dryRun {
+				res, err := tx.ExecContext(ctx, insertTask, task.Name, task.DateCreated, task.DateFinish, doneDate(task.Status, task.DateDone), task.Priority, task.Status, todoID)
+				if err != nil {
+					return report, err
+				}
+
+				id, err := res.LastInsertId()
+				if err != nil {
+					return report, err
+				}
+
+				importedTask.NewID = int(id)
+			}
+
+			names[importKey(task.Name)] = true
+			importedTask.Status = "created"
+			report.CreatedTasks++
+			report.Tasks = append(report.Tasks, importedTask)
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	return report, tx.Commit()
+}
+
+func importKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
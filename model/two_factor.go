@@ -0,0 +1,265 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bicom/todos/utils"
+)
+
+//CreateTOTPTable keeps the authenticator secret of a user, enabled stays 0 until a code confirmed it ...
+var CreateTOTPTable = `CREATE TABLE totp(
+	userID INT(11) NOT NULL,
+	secret VARCHAR(64) NOT NULL,
+	created BIGINT NOT NULL,
+	enabled BIGINT NOT NULL DEFAULT '0',
+	lastStep BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(userID)
+	);
+	`
+
+//CreateRecoveryCodeTable ...
+var CreateRecoveryCodeTable = `CREATE TABLE recovery_code(
+	codeHash CHAR(64) NOT NULL,
+	userID INT(11) NOT NULL,
+	used BIGINT NOT NULL DEFAULT '0',
+	PRIMARY KEY(codeHash),
+	KEY(userID)
+	);
+	`
+
+//CreateLoginChallengeTable keeps logins whose password was right until the second factor comes ...
+var CreateLoginChallengeTable = `CREATE TABLE login_challenge(
+	tokenHash CHAR(64) NOT NULL,
+	userID INT(11) NOT NULL,
+	created BIGINT NOT NULL,
+	expires BIGINT NOT NULL,
+	attempts INT(11) NOT NULL DEFAULT '0',
+	PRIMARY KEY(tokenHash),
+	KEY(expires)
+	);
+	`
+
+//MaxChallengeAttempts is how many codes one login challenge takes before it is spent
+const MaxChallengeAttempts = 5
+
+var (
+	//ErrTwoFactorEnabled ...
+	ErrTwoFactorEnabled = errors.New("Two-factor authentication is already on")
+	//ErrTwoFactorDisabled ...
+	ErrTwoFactorDisabled = errors.New("Two-factor authentication is off")
+	//ErrCodeInvalid covers wrong, replayed and used codes alike ...
+	ErrCodeInvalid = errors.New("Invalid code")
+	//ErrChallengeInvalid covers unknown, expired and spent login challenges alike ...
+	ErrChallengeInvalid = errors.New("Invalid or expired login challenge")
+)
+
+//TwoFactor is the TOTP enrollment of a user ...
+type TwoFactor struct {
+	UserID   int    `db:"userID"`
+	Secret   string `db:"secret"`
+	Created  int64  `db:"created"`
+	Enabled  int64  `db:"enabled"`  //unix time of the confirmation, 0 while pending
+	LastStep int64  `db:"lastStep"` //time step of the last accepted code, codes can't be replayed
+}
+
+//GetTwoFactor returns sql.ErrNoRows for users who never enrolled ...
+func GetTwoFactor(ctx context.Context, userID int) (TwoFactor, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetTwoFactor")
+	defer done()
+
+	var tf TwoFactor
+
+	err := db.GetContext(ctx, &tf, "SELECT * FROM totp WHERE userID=?", userID)
+
+	return tf, err
+}
+
+//SaveTOTPSecret starts an enrollment, replacing one that was never confirmed ...
+func SaveTOTPSecret(ctx context.Context, userID int, secret string, now time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "SaveTOTPSecret")
+	defer done()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO totp (userID, secret, created) VALUES(?, ?, ?)
+		ON DUPLICATE KEY UPDATE secret=IF(enabled=0, VALUES(secret), secret), created=IF(enabled=0, VALUES(created), created)`,
+		userID, secret, now.Unix())
+	if err != nil {
+		return err
+	}
+
+	//MySQL counts 0 rows when the ON DUPLICATE KEY UPDATE changed nothing, that is when 2FA is on
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = ErrTwoFactorEnabled
+		}
+		return err
+	}
+
+	return nil
+}
+
+//EnableTwoFactor confirms the enrollment with the step of the first code and stores the hashes of the
+//recovery codes, earlier codes stop working ...
+func EnableTwoFactor(ctx context.Context, userID int, step int64, recoveryCodes []string, now time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "EnableTwoFactor")
+	defer done()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE totp SET enabled=?, lastStep=? WHERE userID=? AND enabled=0", now.Unix(), step, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = ErrTwoFactorEnabled
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM recovery_code WHERE userID=?", userID)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range recoveryCodes {
+		_, err = tx.ExecContext(ctx, "INSERT INTO recovery_code (codeHash, userID) VALUES(?, ?)", HashToken(normalizeCode(code)), userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+//DisableTwoFactor drops the secret and the recovery codes of the user ...
+func DisableTwoFactor(ctx context.Context, userID int) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "DisableTwoFactor")
+	defer done()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM recovery_code WHERE userID=?", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM totp WHERE userID=?", userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//CheckSecondFactor accepts a TOTP code of a step after the last one used, or an unused recovery code
+//which is then spent ...
+func CheckSecondFactor(ctx context.Context, tf TwoFactor, code string, now time.Time) error {
+	if tf.Enabled == 0 {
+		return ErrTwoFactorDisabled
+	}
+
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CheckSecondFactor")
+	defer done()
+
+	var res sql.Result
+	var err error
+
+	if step, ok := utils.CheckTOTP(tf.Secret, code, now); ok {
+		res, err = db.ExecContext(ctx, "UPDATE totp SET lastStep=? WHERE userID=? AND lastStep < ?", step, tf.UserID, step)
+	} else {
+		res, err = db.ExecContext(ctx, "UPDATE recovery_code SET used=? WHERE codeHash=? AND userID=? AND used=0",
+			now.Unix(), HashToken(normalizeCode(code)), tf.UserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = ErrCodeInvalid
+		}
+		return err
+	}
+
+	return nil
+}
+
+//normalizeCode lets recovery codes be typed without the dash and in any case
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+//CreateLoginChallenge stores token for a login of userID that waits for its second factor, and drops
+//expired challenges ...
+func CreateLoginChallenge(ctx context.Context, userID int, token string, now, expires time.Time) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "CreateLoginChallenge")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM login_challenge WHERE expires < ?", now.Unix())
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO login_challenge (tokenHash, userID, created, expires) VALUES(?, ?, ?, ?)",
+		HashToken(token), userID, now.Unix(), expires.Unix())
+
+	return err
+}
+
+//AttemptLoginChallenge counts an attempt on the challenge and returns its user, a challenge takes
+//MaxChallengeAttempts codes at most ...
+func AttemptLoginChallenge(ctx context.Context, token string, now time.Time) (int, error) {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "AttemptLoginChallenge")
+	defer done()
+
+	res, err := db.ExecContext(ctx, "UPDATE login_challenge SET attempts=attempts+1 WHERE tokenHash=? AND expires > ? AND attempts < ?",
+		HashToken(token), now.Unix(), MaxChallengeAttempts)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = ErrChallengeInvalid
+		}
+		return 0, err
+	}
+
+	var userID int
+
+	err = db.GetContext(ctx, &userID, "SELECT userID FROM login_challenge WHERE tokenHash=?", HashToken(token))
+	if err == sql.ErrNoRows {
+		return 0, ErrChallengeInvalid
+	}
+
+	return userID, err
+}
+
+//DeleteLoginChallenge spends the challenge once its login succeeded ...
+func DeleteLoginChallenge(ctx context.Context, token string) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "DeleteLoginChallenge")
+	defer done()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM login_challenge WHERE tokenHash=?", HashToken(token))
+
+	return err
+}
@@ -1,23 +1,43 @@
 package model
 
 import (
+	"context"
 	"errors"
-	"fmt"
 
 	"database/sql"
 
 	"github.com/bicom/todos/utils"
 	"github.com/casbin/casbin"
+	"github.com/jmoiron/sqlx"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte("mykey")
-
 var (
 	//UserTypeAdmin ...
 	UserTypeAdmin = "admin"
+
+	//ErrUserNotFound ...
+	ErrUserNotFound = errors.New("User does not exist")
+
+	//ErrPasswordInvalid ...
+	ErrPasswordInvalid = errors.New("Invalid password")
 )
 
+//CreateUsersTable is the users table the first releases shipped without a migration for ...
+var CreateUsersTable = `CREATE TABLE users(
+	id INT(11) NOT NULL AUTO_INCREMENT,
+	type VARCHAR(50) NOT NULL DEFAULT 'user',
+	firstname VARCHAR(150),
+	lastname VARCHAR(150),
+	username VARCHAR(150) NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	email VARCHAR(255),
+	PRIMARY KEY(id),
+	UNIQUE KEY(username),
+	KEY(email)
+	);
+	`
+
 //User .
 type User struct {
 	Type            string                    `db:"type" json:"type"`
@@ -27,9 +47,15 @@ type User struct {
 	Username        string                    `db:"username" json:"username"`
 	Password        string                    `db:"password" json:"password"`
 	Email           string                    `db:"email" json:"email"`
+	EmailVerified   int64                     `db:"emailVerified" json:"emailVerified"` //unix time, 0 until the address is verified
 	Token           string                    `db:"token" json:"token"`
 	Issued          int64                     `db:"issued" json:"issued"`
 	UserPermissions map[string]PathPermission `db:"-" json:"user_permissions"`
+	SessionID       string                    `db:"-" json:"-"` //session of the access token in Token
+	RefreshToken    string                    `db:"-" json:"refresh_token,omitempty"`
+	RefreshExpires  int64                     `db:"-" json:"refresh_expires,omitempty"`
+	APITokenID      int                       `db:"-" json:"-"` //set when the request came with an API token
+	Scopes          Scopes                    `db:"-" json:"-"` //of that API token
 }
 
 //PathPermission ...
@@ -43,6 +69,11 @@ func (m *User) IsAdmin() bool {
 	return m.Type == UserTypeAdmin
 }
 
+//TokenAllows checks the scopes of an API token for mode on path, logins aren't limited by scopes ...
+func (m *User) TokenAllows(path string, mode string) bool {
+	return m.APITokenID == 0 || m.Scopes.Allows(path, mode)
+}
+
 //SetPermissions ...
 func (m *User) SetPermissions(rules *casbin.Enforcer) {
 	var userPermissions = make(map[string]PathPermission)
@@ -51,7 +82,6 @@ func (m *User) SetPermissions(rules *casbin.Enforcer) {
 	emailPermissions := rules.GetImplicitPermissionsForUser(m.Email)
 
 	userPermissions = MergePermissions(typePermissions, userPermissions)
-	fmt.Println(userPermissions)
 	userPermissions = MergePermissions(emailPermissions, userPermissions)
 
 	m.UserPermissions = userPermissions
@@ -98,11 +128,13 @@ func MergePermissions(listPermissions [][]string, userPermissions map[string]Pat
 }
 
 //Create ...
-func (m *User) Create() error {
+func (m *User) Create(ctx context.Context) error {
 
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "User.Create")
+	defer done()
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -112,23 +144,32 @@ func (m *User) Create() error {
 		return err
 	}
 
-	_, err = tx.Exec("INSERT INTO users(username, firstname, lastname, email, password, type) values (?, ?, ?, ?, ?, ?)", m.Username, m.FirstName, m.LastName, m.Email, bytes, "user")
+	res, err := tx.ExecContext(ctx, "INSERT INTO users(username, firstname, lastname, email, password, type) values (?, ?, ?, ?, ?, ?)", m.Username, m.FirstName, m.LastName, m.Email, bytes, "user")
 
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	m.ID = int(id)
+
 	tx.Commit()
 
 	return nil
 }
 
 //Login ...
-func (m *User) Login() (User, error) {
+func (m *User) Login(ctx context.Context) (User, error) {
 	var user User
 	db := utils.SQLAcc.GetSQLDB()
-	err := db.Get(&user, "SELECT * FROM users WHERE username = ?", m.Username)
+	ctx, done := utils.ObserveQuery(ctx, "User.Login")
+	defer done()
+	err := db.GetContext(ctx, &user, "SELECT * FROM users WHERE username = ?", m.Username)
 	if err != nil {
 		return user, err
 	}
@@ -141,9 +182,32 @@ func (m *User) Login() (User, error) {
 	return user, nil
 }
 
+//CheckPassword compares password with the stored hash of the user, a mismatch is ErrPasswordInvalid ...
+func (m *User) CheckPassword(ctx context.Context, password string) error {
+	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "User.CheckPassword")
+	defer done()
+
+	var hash string
+
+	err := db.GetContext(ctx, &hash, "SELECT password FROM users WHERE id = ?", m.ID)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrPasswordInvalid
+	}
+
+	return err
+}
+
 //ListUsers ...
-func ListUsers(exclude int) ([]User, error) {
+func ListUsers(ctx context.Context, exclude int) ([]User, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "ListUsers")
+	defer done()
 
 	var rows []User
 	var err error
@@ -153,7 +217,7 @@ func ListUsers(exclude int) ([]User, error) {
 	if exclude != 0 {
 		query += ` WHERE id <> ?`
 
-		err = db.Select(&rows, query, exclude)
+		err = db.SelectContext(ctx, &rows, query, exclude)
 	}
 
 	if err == sql.ErrNoRows {
@@ -167,38 +231,55 @@ func ListUsers(exclude int) ([]User, error) {
 	return rows, nil
 }
 
-//IsLoggedIn ...
-func (m *User) IsLoggedIn(identity string) error {
+//GetUsers returns several users by ID with one query ...
+func GetUsers(ctx context.Context, userIDs []int) ([]User, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetUsers")
+	defer done()
 
-	err := db.Get(m, `SELECT * FROM users WHERE email=? OR username=?`, identity, identity)
+	var users []User
 
-	if err == sql.ErrNoRows {
-		return errors.New("User with email/username '" + identity + "' does not exist")
+	if len(userIDs) == 0 {
+		return users, nil
 	}
 
-	return err
+	query, args, err := sqlx.In("SELECT * FROM users WHERE id IN (?)", userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.SelectContext(ctx, &users, db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
 }
 
-//UpdateTokenInfo ...
-func (m *User) UpdateTokenInfo() error {
+//IsLoggedIn ...
+func (m *User) IsLoggedIn(ctx context.Context, identity string) error {
 	db := utils.SQLAcc.GetSQLDB()
-	_, err := db.Exec(`UPDATE users SET token= ?, issued=? WHERE username=?`, m.Token, m.Issued, m.Username)
+	ctx, done := utils.ObserveQuery(ctx, "User.IsLoggedIn")
+	defer done()
 
-	if err != nil {
-		return err
+	err := db.GetContext(ctx, m, `SELECT * FROM users WHERE email=? OR username=?`, identity, identity)
+
+	if err == sql.ErrNoRows {
+		return errors.New("User with email/username '" + identity + "' does not exist")
 	}
 
-	return nil
+	return err
 }
 
 //UpdatePassword ...
-func (m *User) UpdatePassword(oldpass string, newpass string) error {
+func (m *User) UpdatePassword(ctx context.Context, oldpass string, newpass string) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "User.UpdatePassword")
+	defer done()
 
 	var user User
 
-	err := db.Get(&user, "SELECT * FROM users WHERE username = ?", m.Username)
+	err := db.GetContext(ctx, &user, "SELECT * FROM users WHERE username = ?", m.Username)
 	if err != nil {
 		return err
 	}
@@ -210,7 +291,7 @@ func (m *User) UpdatePassword(oldpass string, newpass string) error {
 	}
 
 	bytes, _ := bcrypt.GenerateFromPassword([]byte(newpass), 12)
-	_, err = db.Exec(`UPDATE users SET password=? WHERE username=?`, bytes, m.Username)
+	_, err = db.ExecContext(ctx, `UPDATE users SET password=? WHERE username=?`, bytes, m.Username)
 
 	if err != nil {
 		return err
@@ -220,10 +301,12 @@ func (m *User) UpdatePassword(oldpass string, newpass string) error {
 }
 
 //UpdateType ...
-func (m *User) UpdateType() error {
+func (m *User) UpdateType(ctx context.Context) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "User.UpdateType")
+	defer done()
 
-	_, err := db.Exec(`UPDATE users SET type=? WHERE id=?`, m.Type, m.ID)
+	_, err := db.ExecContext(ctx, `UPDATE users SET type=? WHERE id=?`, m.Type, m.ID)
 
 	if err != nil {
 		return err
@@ -233,10 +316,12 @@ func (m *User) UpdateType() error {
 }
 
 //DeleteUser ...
-func (m *User) DeleteUser(UserID string) error {
+func (m *User) DeleteUser(ctx context.Context, UserID string) error {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "User.DeleteUser")
+	defer done()
 
-	_, err := db.Exec("DELETE FROM users WHERE id=?", UserID)
+	_, err := db.ExecContext(ctx, "DELETE FROM users WHERE id=?", UserID)
 	if err != nil {
 		return err
 	}
@@ -244,15 +329,17 @@ func (m *User) DeleteUser(UserID string) error {
 }
 
 //GetUser ...
-func (m *User) GetUser(UserID string) (User, error) {
+func (m *User) GetUser(ctx context.Context, UserID string) (User, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "User.GetUser")
+	defer done()
 
 	var user User
 
-	err := db.Get(&user, "SELECT * FROM users WHERE id=?", UserID)
+	err := db.GetContext(ctx, &user, "SELECT * FROM users WHERE id=?", UserID)
 
 	if err == sql.ErrNoRows {
-		return user, errors.New("User does not exist")
+		return user, ErrUserNotFound
 	}
 	if err != nil {
 		return user, err
@@ -261,18 +348,15 @@ func (m *User) GetUser(UserID string) (User, error) {
 	return user, nil
 }
 
-//Clear Token token data used for logout
-func (m *User) Clear() error {
+//GetUserByEmail ...
+func GetUserByEmail(ctx context.Context, email string) (User, error) {
 	db := utils.SQLAcc.GetSQLDB()
+	ctx, done := utils.ObserveQuery(ctx, "GetUserByEmail")
+	defer done()
 
-	m.Token = ""
-	m.Issued = 0
-
-	_, err := db.Exec(`UPDATE users SET token=?, issued=? WHERE username=?`, m.Token, m.Issued, m.Username)
+	var user User
 
-	if err != nil {
-		return err
-	}
+	err := db.GetContext(ctx, &user, "SELECT * FROM users WHERE email=?", email)
 
-	return nil
+	return user, err
 }
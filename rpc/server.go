@@ -0,0 +1,126 @@
+//Package rpc serves the ToDo, Task and User operations over gRPC ...
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative -I todospb todospb/todos.proto
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strconv"
+	"strings"
+
+	middlleware "github.com/bicom/todos/middleware"
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/rpc/todospb"
+	"github.com/bicom/todos/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//Allowed applies the RBAC rules, usually Provider.Allowed ...
+type Allowed func(user model.User, path string, mode string) bool
+
+type userKey struct{}
+
+//publicMethods don't need an authorization header ...
+var publicMethods = map[string]bool{
+	todospb.UserService_Login_FullMethodName: true,
+}
+
+//limitedMethods are rate limited like the REST route they stand for ...
+var limitedMethods = map[string]string{
+	todospb.UserService_Login_FullMethodName: "/login",
+}
+
+//NewServer creates a gRPC server with both services, the rate limit and the JWT interceptors.
+//opts usually carry the TLS credentials of the HTTP server ...
+func NewServer(allowed Allowed, limiter *middlleware.RateLimiter, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(rateLimitInterceptor(limiter), authInterceptor))
+	server := grpc.NewServer(opts...)
+
+	todospb.RegisterUserServiceServer(server, &userService{allowed: allowed})
+	todospb.RegisterToDoServiceServer(server, &todoService{allowed: allowed})
+
+	return server
+}
+
+//rateLimitInterceptor takes from the same per-ip buckets as the REST routes of limitedMethods ...
+func rateLimitInterceptor(limiter *middlleware.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		path, ok := limitedMethods[info.FullMethod]
+		if !ok || limiter == nil {
+			return handler(ctx, req)
+		}
+
+		result := limiter.TakeByIP(ctx, path, client(ctx).IP)
+		if !result.Allowed {
+			retry := int(math.Ceil(result.RetryAfter.Seconds()))
+			grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(retry)))
+
+			return nil, status.Error(codes.ResourceExhausted, "Too many requests")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+//authInterceptor validates the bearer token like Provider.JWT does for REST ...
+func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Authorization metadata is missing")
+	}
+
+	token := values[0]
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = token[7:]
+	}
+
+	user, err := middlleware.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	return handler(context.WithValue(ctx, userKey{}, user), req)
+}
+
+func userFrom(ctx context.Context) model.User {
+	return ctx.Value(userKey{}).(model.User)
+}
+
+func authorize(allowed Allowed, user model.User, path string, mode string) error {
+	if !user.TokenAllows(path, mode) {
+		return status.Error(codes.PermissionDenied, "The scopes of the API token don't allow this")
+	}
+	if allowed != nil && !allowed(user, path, mode) {
+		return status.Error(codes.PermissionDenied, "Acces Forbidden")
+	}
+
+	return nil
+}
+
+//toStatus maps model errors onto gRPC codes ...
+func toStatus(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case model.ErrListForbidden:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case sql.ErrNoRows, model.ErrUserNotFound:
+		return status.Error(codes.NotFound, "Not found")
+	}
+
+	//the cause stays in the log, clients only learn that something failed
+	utils.Logger.WithError(err).Error("gRPC")
+
+	return status.Error(codes.Internal, "Internal error")
+}
@@ -0,0 +1,317 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/rpc/todospb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type todoService struct {
+	todospb.UnimplementedToDoServiceServer
+	allowed Allowed
+}
+
+func toToDo(todo model.ToDo) *todospb.ToDo {
+	return &todospb.ToDo{
+		Id:          int64(todo.ID),
+		Name:        todo.Name,
+		Description: todo.Description,
+		UserId:      int64(todo.UserID),
+	}
+}
+
+func toTask(task model.Task) *todospb.Task {
+	priority, _ := strconv.Atoi(task.Priority)
+
+	return &todospb.Task{
+		Id:          int64(task.ID),
+		Name:        task.Name,
+		DateCreated: task.DateCreated,
+		DateFinish:  task.DateFinish,
+		Priority:    int32(priority),
+		Status:      task.Status,
+		TodoId:      int64(task.ToDoID),
+	}
+}
+
+func toTasks(tasks []model.Task) *todospb.ListTasksResponse {
+	res := &todospb.ListTasksResponse{}
+	for _, task := range tasks {
+		res.Tasks = append(res.Tasks, toTask(task))
+	}
+
+	return res
+}
+
+//ListToDos ...
+func (s *todoService) ListToDos(ctx context.Context, _ *emptypb.Empty) (*todospb.ListToDosResponse, error) {
+	user := userFrom(ctx)
+
+	if err := authorize(s.allowed, user, "/todos", "read"); err != nil {
+		return nil, err
+	}
+
+	owner := user.ID
+	if user.IsAdmin() {
+		owner = 0
+	}
+
+	todos, err := model.ListAllToDos(ctx, owner)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &todospb.ListToDosResponse{}
+	for _, todo := range todos {
+		res.Todos = append(res.Todos, toToDo(todo))
+	}
+
+	return res, nil
+}
+
+//CreateToDo ...
+func (s *todoService) CreateToDo(ctx context.Context, req *todospb.CreateToDoRequest) (*todospb.ToDo, error) {
+	user := userFrom(ctx)
+
+	if err := authorize(s.allowed, user, "/todo", "write"); err != nil {
+		return nil, err
+	}
+
+	todo := model.ToDo{Name: req.Name, Description: req.Description}
+
+	err := todo.CreateToDo(ctx, user.ID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	model.PublishToDoEvent("todo.created", todo, todo)
+
+	return toToDo(todo), nil
+}
+
+//UpdateToDo ...
+func (s *todoService) UpdateToDo(ctx context.Context, req *todospb.UpdateToDoRequest) (*todospb.ToDo, error) {
+	user := userFrom(ctx)
+
+	id := strconv.FormatInt(req.Id, 10)
+
+	todo, err := model.CheckToDoAccess(ctx, user, int(req.Id))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if req.Name != nil {
+		if err := authorize(s.allowed, user, "/todo/name/"+id, "write"); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		if err := authorize(s.allowed, user, "/todo/description/"+id, "write"); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Name != nil {
+		todo.Name = *req.Name
+		if err = todo.UpdateToDoName(ctx, todo.ID); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	if req.Description != nil {
+		todo.Description = *req.Description
+		if err = todo.UpdateToDoDescription(ctx, todo.ID); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+
+	model.PublishToDoEvent("todo.updated", todo, todo)
+
+	return toToDo(todo), nil
+}
+
+//DeleteToDo ...
+func (s *todoService) DeleteToDo(ctx context.Context, req *todospb.DeleteToDoRequest) (*emptypb.Empty, error) {
+	user := userFrom(ctx)
+
+	if err := authorize(s.allowed, user, "/todo/"+strconv.FormatInt(req.Id, 10), "write"); err != nil {
+		return nil, err
+	}
+
+	todo, err := model.CheckToDoAccess(ctx, user, int(req.Id))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	owner := user.ID
+	if user.IsAdmin() {
+		owner = 0
+	}
+
+	err = todo.DeleteToDo(ctx, owner, todo.ID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	model.PublishToDoEvent("todo.deleted", todo, todo)
+
+	return &emptypb.Empty{}, nil
+}
+
+//ListTasks ...
+func (s *todoService) ListTasks(ctx context.Context, req *todospb.ListTasksRequest) (*todospb.ListTasksResponse, error) {
+	user := userFrom(ctx)
+
+	id := strconv.FormatInt(req.TodoId, 10)
+
+	path := "/task/" + id
+	switch req.Filter {
+	case todospb.ListTasksRequest_ACTIVE:
+		path = "/tasks/active/" + id
+	case todospb.ListTasksRequest_COMPLETED:
+		path = "/tasks/completed/" + id
+	}
+
+	if err := authorize(s.allowed, user, path, "read"); err != nil {
+		return nil, err
+	}
+
+	todo, err := model.CheckToDoAccess(ctx, user, int(req.TodoId))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var tasks []model.Task
+
+	switch req.Filter {
+	case todospb.ListTasksRequest_ACTIVE:
+		tasks, err = model.ListAllActiveTasks(ctx, todo.ID)
+	case todospb.ListTasksRequest_COMPLETED:
+		tasks, err = model.ListCompletedTasks(ctx, id)
+	default:
+		tasks, err = model.ListTasks(ctx, todo.ID)
+	}
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTasks(tasks), nil
+}
+
+//CreateTask ...
+func (s *todoService) CreateTask(ctx context.Context, req *todospb.CreateTaskRequest) (*todospb.Task, error) {
+	user := userFrom(ctx)
+
+	if err := authorize(s.allowed, user, "/task/"+strconv.FormatInt(req.TodoId, 10), "write"); err != nil {
+		return nil, err
+	}
+
+	todo, err := model.CheckToDoAccess(ctx, user, int(req.TodoId))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	task := model.Task{Priority: "0"}
+	if req.Task != nil {
+		task.Name = req.Task.Name
+		task.DateCreated = req.Task.DateCreated
+		task.DateFinish = req.Task.DateFinish
+		task.Priority = strconv.Itoa(int(req.Task.Priority))
+		task.Status = req.Task.Status
+	}
+
+	err = task.CreateTask(ctx, todo.ID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	model.PublishTaskEvent(ctx, "task.created", todo.ID, task.ID)
+
+	return toTask(task), nil
+}
+
+//UpdateTask applies every given field, each one guarded like its REST route ...
+func (s *todoService) UpdateTask(ctx context.Context, req *todospb.UpdateTaskRequest) (*todospb.Task, error) {
+	user := userFrom(ctx)
+
+	task, todo, err := model.CheckTaskAccess(ctx, user, int(req.Id))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	id := strconv.Itoa(todo.ID)
+
+	checks := []struct {
+		set  bool
+		path string
+	}{
+		{req.Name != nil, "/task/name/" + id},
+		{req.DateFinish != nil, "/task/date/" + id},
+		{req.Priority != nil, "/task/priority/" + id},
+		{req.Status != nil, "/task/status/" + id},
+	}
+
+	for _, check := range checks {
+		if !check.set {
+			continue
+		}
+		if err := authorize(s.allowed, user, check.path, "write"); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Name != nil {
+		task.Name = *req.Name
+		if err = task.UpdateTaskName(ctx, todo.ID, task.ID); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	if req.DateFinish != nil {
+		task.DateFinish = *req.DateFinish
+		if err = task.UpdateTaskDateFinish(ctx, todo.ID, task.ID); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	if req.Priority != nil {
+		task.Priority = strconv.Itoa(int(*req.Priority))
+		if err = task.UpdateTaskPriority(ctx, todo.ID, task.ID); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	if req.Status != nil {
+		task.Status = *req.Status
+		if err = task.UpdateTaskStatus(ctx, todo.ID, task.ID); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+
+	model.PublishTaskEvent(ctx, "task.updated", todo.ID, task.ID)
+
+	return toTask(task), nil
+}
+
+//DeleteTask ...
+func (s *todoService) DeleteTask(ctx context.Context, req *todospb.DeleteTaskRequest) (*emptypb.Empty, error) {
+	user := userFrom(ctx)
+
+	if err := authorize(s.allowed, user, "/task/"+strconv.FormatInt(req.Id, 10), "write"); err != nil {
+		return nil, err
+	}
+
+	task, todo, err := model.CheckTaskAccess(ctx, user, int(req.Id))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	err = task.DeleteTask(ctx, todo.ID, task.ID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	model.PublishToDoEvent("task.deleted", todo, task)
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,1351 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: todos.proto
+
+package todospb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListTasksRequest_Filter int32
+
+const (
+	ListTasksRequest_ALL       ListTasksRequest_Filter = 0
+	ListTasksRequest_ACTIVE    ListTasksRequest_Filter = 1
+	ListTasksRequest_COMPLETED ListTasksRequest_Filter = 2
+)
+
+// Enum value maps for ListTasksRequest_Filter.
+var (
+	ListTasksRequest_Filter_name = map[int32]string{
+		0: "ALL",
+		1: "ACTIVE",
+		2: "COMPLETED",
+	}
+	ListTasksRequest_Filter_value = map[string]int32{
+		"ALL":       0,
+		"ACTIVE":    1,
+		"COMPLETED": 2,
+	}
+)
+
+func (x ListTasksRequest_Filter) Enum() *ListTasksRequest_Filter {
+	p := new(ListTasksRequest_Filter)
+	*p = x
+	return p
+}
+
+func (x ListTasksRequest_Filter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ListTasksRequest_Filter) Descriptor() protoreflect.EnumDescriptor {
+	return file_todos_proto_enumTypes[0].Descriptor()
+}
+
+func (ListTasksRequest_Filter) Type() protoreflect.EnumType {
+	return &file_todos_proto_enumTypes[0]
+}
+
+func (x ListTasksRequest_Filter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ListTasksRequest_Filter.Descriptor instead.
+func (ListTasksRequest_Filter) EnumDescriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{14, 0}
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Firstname     string                 `protobuf:"bytes,4,opt,name=firstname,proto3" json:"firstname,omitempty"`
+	Lastname      string                 `protobuf:"bytes,5,opt,name=lastname,proto3" json:"lastname,omitempty"`
+	Email         string                 `protobuf:"bytes,6,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_todos_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetFirstname() string {
+	if x != nil {
+		return x.Firstname
+	}
+	return ""
+}
+
+func (x *User) GetLastname() string {
+	if x != nil {
+		return x.Lastname
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type ToDo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	UserId        int64                  `protobuf:"varint,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToDo) Reset() {
+	*x = ToDo{}
+	mi := &file_todos_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToDo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToDo) ProtoMessage() {}
+
+func (x *ToDo) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToDo.ProtoReflect.Descriptor instead.
+func (*ToDo) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ToDo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ToDo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToDo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ToDo) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type Task struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	DateCreated string                 `protobuf:"bytes,3,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	DateFinish  string                 `protobuf:"bytes,4,opt,name=date_finish,json=dateFinish,proto3" json:"date_finish,omitempty"`
+	// 1 (highest) - 5, 0 when not set
+	Priority      int32 `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	Status        bool  `protobuf:"varint,6,opt,name=status,proto3" json:"status,omitempty"`
+	TodoId        int64 `protobuf:"varint,7,opt,name=todo_id,json=todoId,proto3" json:"todo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Task) Reset() {
+	*x = Task{}
+	mi := &file_todos_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Task) ProtoMessage() {}
+
+func (x *Task) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Task.ProtoReflect.Descriptor instead.
+func (*Task) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Task) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Task) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Task) GetDateCreated() string {
+	if x != nil {
+		return x.DateCreated
+	}
+	return ""
+}
+
+func (x *Task) GetDateFinish() string {
+	if x != nil {
+		return x.DateFinish
+	}
+	return ""
+}
+
+func (x *Task) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Task) GetStatus() bool {
+	if x != nil {
+		return x.Status
+	}
+	return false
+}
+
+func (x *Task) GetTodoId() int64 {
+	if x != nil {
+		return x.TodoId
+	}
+	return 0
+}
+
+type LoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_todos_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LoginRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	User  *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// send as "authorization: Bearer <token>" metadata
+	Token         string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Expires       int64  `protobuf:"varint,3,opt,name=expires,proto3" json:"expires,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	mi := &file_todos_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LoginResponse) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *LoginResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetExpires() int64 {
+	if x != nil {
+		return x.Expires
+	}
+	return 0
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_todos_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersResponse) Reset() {
+	*x = ListUsersResponse{}
+	mi := &file_todos_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersResponse) ProtoMessage() {}
+
+func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type UpdatePasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Oldpass       string                 `protobuf:"bytes,1,opt,name=oldpass,proto3" json:"oldpass,omitempty"`
+	Newpass       string                 `protobuf:"bytes,2,opt,name=newpass,proto3" json:"newpass,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdatePasswordRequest) Reset() {
+	*x = UpdatePasswordRequest{}
+	mi := &file_todos_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdatePasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePasswordRequest) ProtoMessage() {}
+
+func (x *UpdatePasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePasswordRequest.ProtoReflect.Descriptor instead.
+func (*UpdatePasswordRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdatePasswordRequest) GetOldpass() string {
+	if x != nil {
+		return x.Oldpass
+	}
+	return ""
+}
+
+func (x *UpdatePasswordRequest) GetNewpass() string {
+	if x != nil {
+		return x.Newpass
+	}
+	return ""
+}
+
+type UpdateUserTypeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserTypeRequest) Reset() {
+	*x = UpdateUserTypeRequest{}
+	mi := &file_todos_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserTypeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserTypeRequest) ProtoMessage() {}
+
+func (x *UpdateUserTypeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserTypeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserTypeRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateUserTypeRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateUserTypeRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type DeleteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRequest) Reset() {
+	*x = DeleteUserRequest{}
+	mi := &file_todos_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRequest) ProtoMessage() {}
+
+func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListToDosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Todos         []*ToDo                `protobuf:"bytes,1,rep,name=todos,proto3" json:"todos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToDosResponse) Reset() {
+	*x = ListToDosResponse{}
+	mi := &file_todos_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToDosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToDosResponse) ProtoMessage() {}
+
+func (x *ListToDosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToDosResponse.ProtoReflect.Descriptor instead.
+func (*ListToDosResponse) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListToDosResponse) GetTodos() []*ToDo {
+	if x != nil {
+		return x.Todos
+	}
+	return nil
+}
+
+type CreateToDoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateToDoRequest) Reset() {
+	*x = CreateToDoRequest{}
+	mi := &file_todos_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateToDoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateToDoRequest) ProtoMessage() {}
+
+func (x *CreateToDoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateToDoRequest.ProtoReflect.Descriptor instead.
+func (*CreateToDoRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateToDoRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateToDoRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type UpdateToDoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Description   *string                `protobuf:"bytes,3,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateToDoRequest) Reset() {
+	*x = UpdateToDoRequest{}
+	mi := &file_todos_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateToDoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateToDoRequest) ProtoMessage() {}
+
+func (x *UpdateToDoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateToDoRequest.ProtoReflect.Descriptor instead.
+func (*UpdateToDoRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateToDoRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateToDoRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateToDoRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+type DeleteToDoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteToDoRequest) Reset() {
+	*x = DeleteToDoRequest{}
+	mi := &file_todos_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteToDoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteToDoRequest) ProtoMessage() {}
+
+func (x *DeleteToDoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteToDoRequest.ProtoReflect.Descriptor instead.
+func (*DeleteToDoRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeleteToDoRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListTasksRequest struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	TodoId        int64                   `protobuf:"varint,1,opt,name=todo_id,json=todoId,proto3" json:"todo_id,omitempty"`
+	Filter        ListTasksRequest_Filter `protobuf:"varint,2,opt,name=filter,proto3,enum=todos.v1.ListTasksRequest_Filter" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksRequest) Reset() {
+	*x = ListTasksRequest{}
+	mi := &file_todos_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksRequest) ProtoMessage() {}
+
+func (x *ListTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksRequest.ProtoReflect.Descriptor instead.
+func (*ListTasksRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListTasksRequest) GetTodoId() int64 {
+	if x != nil {
+		return x.TodoId
+	}
+	return 0
+}
+
+func (x *ListTasksRequest) GetFilter() ListTasksRequest_Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return ListTasksRequest_ALL
+}
+
+type ListTasksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tasks         []*Task                `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksResponse) Reset() {
+	*x = ListTasksResponse{}
+	mi := &file_todos_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksResponse) ProtoMessage() {}
+
+func (x *ListTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksResponse.ProtoReflect.Descriptor instead.
+func (*ListTasksResponse) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListTasksResponse) GetTasks() []*Task {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+type CreateTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TodoId        int64                  `protobuf:"varint,1,opt,name=todo_id,json=todoId,proto3" json:"todo_id,omitempty"`
+	Task          *Task                  `protobuf:"bytes,2,opt,name=task,proto3" json:"task,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTaskRequest) Reset() {
+	*x = CreateTaskRequest{}
+	mi := &file_todos_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTaskRequest) ProtoMessage() {}
+
+func (x *CreateTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTaskRequest.ProtoReflect.Descriptor instead.
+func (*CreateTaskRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreateTaskRequest) GetTodoId() int64 {
+	if x != nil {
+		return x.TodoId
+	}
+	return 0
+}
+
+func (x *CreateTaskRequest) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+type UpdateTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	DateFinish    *string                `protobuf:"bytes,3,opt,name=date_finish,json=dateFinish,proto3,oneof" json:"date_finish,omitempty"`
+	Priority      *int32                 `protobuf:"varint,4,opt,name=priority,proto3,oneof" json:"priority,omitempty"`
+	Status        *bool                  `protobuf:"varint,5,opt,name=status,proto3,oneof" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTaskRequest) Reset() {
+	*x = UpdateTaskRequest{}
+	mi := &file_todos_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTaskRequest) ProtoMessage() {}
+
+func (x *UpdateTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTaskRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTaskRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UpdateTaskRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateTaskRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateTaskRequest) GetDateFinish() string {
+	if x != nil && x.DateFinish != nil {
+		return *x.DateFinish
+	}
+	return ""
+}
+
+func (x *UpdateTaskRequest) GetPriority() int32 {
+	if x != nil && x.Priority != nil {
+		return *x.Priority
+	}
+	return 0
+}
+
+func (x *UpdateTaskRequest) GetStatus() bool {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return false
+}
+
+type DeleteTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTaskRequest) Reset() {
+	*x = DeleteTaskRequest{}
+	mi := &file_todos_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTaskRequest) ProtoMessage() {}
+
+func (x *DeleteTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todos_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTaskRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTaskRequest) Descriptor() ([]byte, []int) {
+	return file_todos_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DeleteTaskRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+var File_todos_proto protoreflect.FileDescriptor
+
+const file_todos_proto_rawDesc = "" +
+	"\n" +
+	"\vtodos.proto\x12\btodos.v1\x1a\x1bgoogle/protobuf/empty.proto\"\x96\x01\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12\x1c\n" +
+	"\tfirstname\x18\x04 \x01(\tR\tfirstname\x12\x1a\n" +
+	"\blastname\x18\x05 \x01(\tR\blastname\x12\x14\n" +
+	"\x05email\x18\x06 \x01(\tR\x05email\"e\n" +
+	"\x04ToDo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\x03R\x06userId\"\xbb\x01\n" +
+	"\x04Task\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\fdate_created\x18\x03 \x01(\tR\vdateCreated\x12\x1f\n" +
+	"\vdate_finish\x18\x04 \x01(\tR\n" +
+	"dateFinish\x12\x1a\n" +
+	"\bpriority\x18\x05 \x01(\x05R\bpriority\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\bR\x06status\x12\x17\n" +
+	"\atodo_id\x18\a \x01(\x03R\x06todoId\"F\n" +
+	"\fLoginRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"c\n" +
+	"\rLoginResponse\x12\"\n" +
+	"\x04user\x18\x01 \x01(\v2\x0e.todos.v1.UserR\x04user\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x18\n" +
+	"\aexpires\x18\x03 \x01(\x03R\aexpires\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"9\n" +
+	"\x11ListUsersResponse\x12$\n" +
+	"\x05users\x18\x01 \x03(\v2\x0e.todos.v1.UserR\x05users\"K\n" +
+	"\x15UpdatePasswordRequest\x12\x18\n" +
+	"\aoldpass\x18\x01 \x01(\tR\aoldpass\x12\x18\n" +
+	"\anewpass\x18\x02 \x01(\tR\anewpass\";\n" +
+	"\x15UpdateUserTypeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\"#\n" +
+	"\x11DeleteUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"9\n" +
+	"\x11ListToDosResponse\x12$\n" +
+	"\x05todos\x18\x01 \x03(\v2\x0e.todos.v1.ToDoR\x05todos\"I\n" +
+	"\x11CreateToDoRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"|\n" +
+	"\x11UpdateToDoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x03 \x01(\tH\x01R\vdescription\x88\x01\x01B\a\n" +
+	"\x05_nameB\x0e\n" +
+	"\f_description\"#\n" +
+	"\x11DeleteToDoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x94\x01\n" +
+	"\x10ListTasksRequest\x12\x17\n" +
+	"\atodo_id\x18\x01 \x01(\x03R\x06todoId\x129\n" +
+	"\x06filter\x18\x02 \x01(\x0e2!.todos.v1.ListTasksRequest.FilterR\x06filter\",\n" +
+	"\x06Filter\x12\a\n" +
+	"\x03ALL\x10\x00\x12\n" +
+	"\n" +
+	"\x06ACTIVE\x10\x01\x12\r\n" +
+	"\tCOMPLETED\x10\x02\"9\n" +
+	"\x11ListTasksResponse\x12$\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x0e.todos.v1.TaskR\x05tasks\"P\n" +
+	"\x11CreateTaskRequest\x12\x17\n" +
+	"\atodo_id\x18\x01 \x01(\x03R\x06todoId\x12\"\n" +
+	"\x04task\x18\x02 \x01(\v2\x0e.todos.v1.TaskR\x04task\"\xd1\x01\n" +
+	"\x11UpdateTaskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12$\n" +
+	"\vdate_finish\x18\x03 \x01(\tH\x01R\n" +
+	"dateFinish\x88\x01\x01\x12\x1f\n" +
+	"\bpriority\x18\x04 \x01(\x05H\x02R\bpriority\x88\x01\x01\x12\x1b\n" +
+	"\x06status\x18\x05 \x01(\bH\x03R\x06status\x88\x01\x01B\a\n" +
+	"\x05_nameB\x0e\n" +
+	"\f_date_finishB\v\n" +
+	"\t_priorityB\t\n" +
+	"\a_status\"#\n" +
+	"\x11DeleteTaskRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id2\xd1\x03\n" +
+	"\vUserService\x128\n" +
+	"\x05Login\x12\x16.todos.v1.LoginRequest\x1a\x17.todos.v1.LoginResponse\x128\n" +
+	"\x06Logout\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x123\n" +
+	"\aGetUser\x12\x18.todos.v1.GetUserRequest\x1a\x0e.todos.v1.User\x12@\n" +
+	"\tListUsers\x12\x16.google.protobuf.Empty\x1a\x1b.todos.v1.ListUsersResponse\x12I\n" +
+	"\x0eUpdatePassword\x12\x1f.todos.v1.UpdatePasswordRequest\x1a\x16.google.protobuf.Empty\x12I\n" +
+	"\x0eUpdateUserType\x12\x1f.todos.v1.UpdateUserTypeRequest\x1a\x16.google.protobuf.Empty\x12A\n" +
+	"\n" +
+	"DeleteUser\x12\x1b.todos.v1.DeleteUserRequest\x1a\x16.google.protobuf.Empty2\x87\x04\n" +
+	"\vToDoService\x12@\n" +
+	"\tListToDos\x12\x16.google.protobuf.Empty\x1a\x1b.todos.v1.ListToDosResponse\x129\n" +
+	"\n" +
+	"CreateToDo\x12\x1b.todos.v1.CreateToDoRequest\x1a\x0e.todos.v1.ToDo\x129\n" +
+	"\n" +
+	"UpdateToDo\x12\x1b.todos.v1.UpdateToDoRequest\x1a\x0e.todos.v1.ToDo\x12A\n" +
+	"\n" +
+	"DeleteToDo\x12\x1b.todos.v1.DeleteToDoRequest\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\tListTasks\x12\x1a.todos.v1.ListTasksRequest\x1a\x1b.todos.v1.ListTasksResponse\x129\n" +
+	"\n" +
+	"CreateTask\x12\x1b.todos.v1.CreateTaskRequest\x1a\x0e.todos.v1.Task\x129\n" +
+	"\n" +
+	"UpdateTask\x12\x1b.todos.v1.UpdateTaskRequest\x1a\x0e.todos.v1.Task\x12A\n" +
+	"\n" +
+	"DeleteTask\x12\x1b.todos.v1.DeleteTaskRequest\x1a\x16.google.protobuf.EmptyB$Z\"github.com/bicom/todos/rpc/todospbb\x06proto3"
+
+var (
+	file_todos_proto_rawDescOnce sync.Once
+	file_todos_proto_rawDescData []byte
+)
+
+func file_todos_proto_rawDescGZIP() []byte {
+	file_todos_proto_rawDescOnce.Do(func() {
+		file_todos_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_todos_proto_rawDesc), len(file_todos_proto_rawDesc)))
+	})
+	return file_todos_proto_rawDescData
+}
+
+var file_todos_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_todos_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_todos_proto_goTypes = []any{
+	(ListTasksRequest_Filter)(0),  // 0: todos.v1.ListTasksRequest.Filter
+	(*User)(nil),                  // 1: todos.v1.User
+	(*ToDo)(nil),                  // 2: todos.v1.ToDo
+	(*Task)(nil),                  // 3: todos.v1.Task
+	(*LoginRequest)(nil),          // 4: todos.v1.LoginRequest
+	(*LoginResponse)(nil),         // 5: todos.v1.LoginResponse
+	(*GetUserRequest)(nil),        // 6: todos.v1.GetUserRequest
+	(*ListUsersResponse)(nil),     // 7: todos.v1.ListUsersResponse
+	(*UpdatePasswordRequest)(nil), // 8: todos.v1.UpdatePasswordRequest
+	(*UpdateUserTypeRequest)(nil), // 9: todos.v1.UpdateUserTypeRequest
+	(*DeleteUserRequest)(nil),     // 10: todos.v1.DeleteUserRequest
+	(*ListToDosResponse)(nil),     // 11: todos.v1.ListToDosResponse
+	(*CreateToDoRequest)(nil),     // 12: todos.v1.CreateToDoRequest
+	(*UpdateToDoRequest)(nil),     // 13: todos.v1.UpdateToDoRequest
+	(*DeleteToDoRequest)(nil),     // 14: todos.v1.DeleteToDoRequest
+	(*ListTasksRequest)(nil),      // 15: todos.v1.ListTasksRequest
+	(*ListTasksResponse)(nil),     // 16: todos.v1.ListTasksResponse
+	(*CreateTaskRequest)(nil),     // 17: todos.v1.CreateTaskRequest
+	(*UpdateTaskRequest)(nil),     // 18: todos.v1.UpdateTaskRequest
+	(*DeleteTaskRequest)(nil),     // 19: todos.v1.DeleteTaskRequest
+	(*emptypb.Empty)(nil),         // 20: google.protobuf.Empty
+}
+var file_todos_proto_depIdxs = []int32{
+	1,  // 0: todos.v1.LoginResponse.user:type_name -> todos.v1.User
+	1,  // 1: todos.v1.ListUsersResponse.users:type_name -> todos.v1.User
+	2,  // 2: todos.v1.ListToDosResponse.todos:type_name -> todos.v1.ToDo
+	0,  // 3: todos.v1.ListTasksRequest.filter:type_name -> todos.v1.ListTasksRequest.Filter
+	3,  // 4: todos.v1.ListTasksResponse.tasks:type_name -> todos.v1.Task
+	3,  // 5: todos.v1.CreateTaskRequest.task:type_name -> todos.v1.Task
+	4,  // 6: todos.v1.UserService.Login:input_type -> todos.v1.LoginRequest
+	20, // 7: todos.v1.UserService.Logout:input_type -> google.protobuf.Empty
+	6,  // 8: todos.v1.UserService.GetUser:input_type -> todos.v1.GetUserRequest
+	20, // 9: todos.v1.UserService.ListUsers:input_type -> google.protobuf.Empty
+	8,  // 10: todos.v1.UserService.UpdatePassword:input_type -> todos.v1.UpdatePasswordRequest
+	9,  // 11: todos.v1.UserService.UpdateUserType:input_type -> todos.v1.UpdateUserTypeRequest
+	10, // 12: todos.v1.UserService.DeleteUser:input_type -> todos.v1.DeleteUserRequest
+	20, // 13: todos.v1.ToDoService.ListToDos:input_type -> google.protobuf.Empty
+	12, // 14: todos.v1.ToDoService.CreateToDo:input_type -> todos.v1.CreateToDoRequest
+	13, // 15: todos.v1.ToDoService.UpdateToDo:input_type -> todos.v1.UpdateToDoRequest
+	14, // 16: todos.v1.ToDoService.DeleteToDo:input_type -> todos.v1.DeleteToDoRequest
+	15, // 17: todos.v1.ToDoService.ListTasks:input_type -> todos.v1.ListTasksRequest
+	17, // 18: todos.v1.ToDoService.CreateTask:input_type -> todos.v1.CreateTaskRequest
+	18, // 19: todos.v1.ToDoService.UpdateTask:input_type -> todos.v1.UpdateTaskRequest
+	19, // 20: todos.v1.ToDoService.DeleteTask:input_type -> todos.v1.DeleteTaskRequest
+	5,  // 21: todos.v1.UserService.Login:output_type -> todos.v1.LoginResponse
+	20, // 22: todos.v1.UserService.Logout:output_type -> google.protobuf.Empty
+	1,  // 23: todos.v1.UserService.GetUser:output_type -> todos.v1.User
+	7,  // 24: todos.v1.UserService.ListUsers:output_type -> todos.v1.ListUsersResponse
+	20, // 25: todos.v1.UserService.UpdatePassword:output_type -> google.protobuf.Empty
+	20, // 26: todos.v1.UserService.UpdateUserType:output_type -> google.protobuf.Empty
+	20, // 27: todos.v1.UserService.DeleteUser:output_type -> google.protobuf.Empty
+	11, // 28: todos.v1.ToDoService.ListToDos:output_type -> todos.v1.ListToDosResponse
+	2,  // 29: todos.v1.ToDoService.CreateToDo:output_type -> todos.v1.ToDo
+	2,  // 30: todos.v1.ToDoService.UpdateToDo:output_type -> todos.v1.ToDo
+	20, // 31: todos.v1.ToDoService.DeleteToDo:output_type -> google.protobuf.Empty
+	16, // 32: todos.v1.ToDoService.ListTasks:output_type -> todos.v1.ListTasksResponse
+	3,  // 33: todos.v1.ToDoService.CreateTask:output_type -> todos.v1.Task
+	3,  // 34: todos.v1.ToDoService.UpdateTask:output_type -> todos.v1.Task
+	20, // 35: todos.v1.ToDoService.DeleteTask:output_type -> google.protobuf.Empty
+	21, // [21:36] is the sub-list for method output_type
+	6,  // [6:21] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_todos_proto_init() }
+func file_todos_proto_init() {
+	if File_todos_proto != nil {
+		return
+	}
+	file_todos_proto_msgTypes[12].OneofWrappers = []any{}
+	file_todos_proto_msgTypes[17].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_todos_proto_rawDesc), len(file_todos_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_todos_proto_goTypes,
+		DependencyIndexes: file_todos_proto_depIdxs,
+		EnumInfos:         file_todos_proto_enumTypes,
+		MessageInfos:      file_todos_proto_msgTypes,
+	}.Build()
+	File_todos_proto = out.File
+	file_todos_proto_goTypes = nil
+	file_todos_proto_depIdxs = nil
+}
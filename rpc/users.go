@@ -0,0 +1,196 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strconv"
+
+	middlleware "github.com/bicom/todos/middleware"
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/rpc/todospb"
+	"github.com/bicom/todos/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type userService struct {
+	todospb.UnimplementedUserServiceServer
+	allowed Allowed
+}
+
+func toUser(user model.User) *todospb.User {
+	return &todospb.User{
+		Id:        int64(user.ID),
+		Type:      user.Type,
+		Username:  user.Username,
+		Firstname: user.FirstName,
+		Lastname:  user.LastName,
+		Email:     user.Email,
+	}
+}
+
+//Login ...
+func (s *userService) Login(ctx context.Context, req *todospb.LoginRequest) (*todospb.LoginResponse, error) {
+	user, err := middlleware.Login(ctx, req.Username, req.Password, client(ctx))
+	if challenge, ok := err.(middlleware.TwoFactorRequired); ok {
+		//there is no second step over gRPC, the challenge can be finished at POST /login/2fa
+		grpc.SetTrailer(ctx, metadata.Pairs("two-factor-challenge", challenge.Challenge))
+		return nil, status.Error(codes.Unauthenticated, challenge.Message)
+	}
+	if err == middlleware.ErrEmailNotVerified {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		//bcrypt and sql errors stay in the log, the client can't tell a wrong password from a failure
+		utils.Logger.WithError(err).WithField("username", req.Username).Info("gRPC login failed")
+		return nil, status.Error(codes.Unauthenticated, "Invalid username or password")
+	}
+
+	return &todospb.LoginResponse{User: toUser(user), Token: user.Token, Expires: user.Issued}, nil
+}
+
+//client describes the caller for the session list, like the user agent and address of REST logins ...
+func client(ctx context.Context) model.Session {
+	var session model.Session
+
+	if p, ok := peer.FromContext(ctx); ok {
+		session.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(session.IP); err == nil {
+			session.IP = host
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if agent := md.Get("user-agent"); len(agent) > 0 {
+			session.UserAgent = agent[0]
+		}
+		session.IP = middlleware.ForwardedClient(session.IP, md.Get("x-forwarded-for"))
+	}
+
+	return session
+}
+
+//Logout ends the session of the token ...
+func (s *userService) Logout(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	user := userFrom(ctx)
+
+	err := model.RevokeSession(ctx, user.ID, user.SessionID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+//GetUser ...
+func (s *userService) GetUser(ctx context.Context, req *todospb.GetUserRequest) (*todospb.User, error) {
+	user := userFrom(ctx)
+
+	if int(req.Id) != user.ID && !user.IsAdmin() {
+		return nil, status.Error(codes.PermissionDenied, "You are not allowed to see this user")
+	}
+
+	id := strconv.FormatInt(req.Id, 10)
+
+	if err := authorize(s.allowed, user, "/user/"+id, "read"); err != nil {
+		return nil, err
+	}
+
+	found, err := user.GetUser(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toUser(found), nil
+}
+
+//ListUsers ...
+func (s *userService) ListUsers(ctx context.Context, _ *emptypb.Empty) (*todospb.ListUsersResponse, error) {
+	user := userFrom(ctx)
+
+	if !user.IsAdmin() {
+		return nil, status.Error(codes.PermissionDenied, "You ar not allowed to list users")
+	}
+	if err := authorize(s.allowed, user, "/users", "read"); err != nil {
+		return nil, err
+	}
+
+	users, err := model.ListUsers(ctx, user.ID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &todospb.ListUsersResponse{}
+	for _, u := range users {
+		res.Users = append(res.Users, toUser(u))
+	}
+
+	return res, nil
+}
+
+//UpdatePassword ...
+func (s *userService) UpdatePassword(ctx context.Context, req *todospb.UpdatePasswordRequest) (*emptypb.Empty, error) {
+	user := userFrom(ctx)
+
+	if err := authorize(s.allowed, user, "/user/password2", "write"); err != nil {
+		return nil, err
+	}
+
+	if len(req.Newpass) <= 4 {
+		return nil, status.Error(codes.InvalidArgument, "Password must be longer than 4 characters")
+	}
+
+	err := user.UpdatePassword(ctx, req.Oldpass, req.Newpass)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Old passwords do not match")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+//UpdateUserType ...
+func (s *userService) UpdateUserType(ctx context.Context, req *todospb.UpdateUserTypeRequest) (*emptypb.Empty, error) {
+	user := userFrom(ctx)
+
+	if !user.IsAdmin() || req.Type == "" {
+		return nil, status.Error(codes.PermissionDenied, "You have no perrmission to update user type")
+	}
+	if err := authorize(s.allowed, user, "/user/type", "write"); err != nil {
+		return nil, err
+	}
+
+	u := model.User{ID: int(req.Id), Type: req.Type}
+
+	err := u.UpdateType(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+//DeleteUser ...
+func (s *userService) DeleteUser(ctx context.Context, req *todospb.DeleteUserRequest) (*emptypb.Empty, error) {
+	user := userFrom(ctx)
+
+	id := strconv.FormatInt(req.Id, 10)
+
+	if !user.IsAdmin() {
+		return nil, status.Error(codes.PermissionDenied, "You are not allowed to delete user")
+	}
+	if err := authorize(s.allowed, user, "/user/"+id, "write"); err != nil {
+		return nil, err
+	}
+
+	err := user.DeleteUser(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
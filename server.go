@@ -1,48 +1,166 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"crypto/tls"
+	"flag"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/bicom/todos/controller"
 	middlleware "github.com/bicom/todos/middleware"
+	"github.com/bicom/todos/model"
+	"github.com/bicom/todos/rpc"
 	"github.com/bicom/todos/utils"
 	"github.com/julienschmidt/httprouter"
 	"github.com/urfave/negroni"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+//version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 var (
 	users    controller.Users
 	mdlw     middlleware.Middlleware
 	task     controller.ToDoController
+	events   controller.EventsController
+	socket   controller.SocketController
 	provider middlleware.Provider
 )
 
 func main() {
+	//CONFIGURATION
+	conf, err := utils.LoadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//LOGGING
+	err = utils.InitLogger(conf.Env, conf.Log)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//TRACING
+	shutdownTracer, err := utils.InitTracer(conf.Trace)
+	if err != nil {
+		utils.Logger.Fatal(err)
+	}
+
 	//connecting to DB
-	utils.GetSQLDB("dev", "conf/conf.yaml")
+	err = utils.OpenDB(conf.DB)
+	if err != nil {
+		utils.Logger.WithError(err).Error("DB is unreachable, /readyz reports it until it is back")
+	} else if conf.AutoMigrate {
+		err = model.Migrate(context.Background())
+		if err != nil {
+			utils.Logger.WithError(err).Error("DB migrations")
+		}
+	}
+
+	if db := utils.SQLAcc.GetSQLDB(); db != nil {
+		err = utils.RegisterDBStats(db.DB)
+		if err != nil {
+			utils.Logger.WithError(err).Error("DB pool metrics")
+		}
+	}
 
 	//RBAC configuration
-	err := provider.SetRBAC("/conf/rbac.conf", "/conf/policy.csv")
+	err = provider.SetRBAC(conf.RBAC.Model, conf.RBAC.Policy)
+	if err != nil {
+		utils.Logger.WithError(err).Error("RBAC configuration")
+	}
+
+	//MAIL
+	err = utils.InitMailer(conf.Mail)
+	if err != nil {
+		utils.Logger.Fatal(err)
+	}
+
+	//JWT keys, already checked with the configuration
+	keys, err := utils.LoadKeySet(conf.JWT)
 	if err != nil {
-		fmt.Println(err)
+		utils.Logger.Fatal(err)
 	}
+	accessTTL, _ := time.ParseDuration(conf.JWT.AccessTTL)
+	refreshTTL, _ := time.ParseDuration(conf.JWT.RefreshTTL)
+	middlleware.SetJWT(keys, accessTTL, refreshTTL)
+	middlleware.SetAllowedOrigins(conf.HTTP.AllowedOrigins)
+	proxies, _ := utils.ParseNetworks(conf.HTTP.TrustedProxies) //checked with the configuration too
+	middlleware.SetTrustedProxies(proxies)
 
 	//ROUTER
 	mux := httprouter.New()
 
+	graph := controller.NewGraphQLController(provider.Allowed)
+
+	accounts := controller.NewAccountsController(conf.Accounts)
+	users.Accounts = accounts
+	middlleware.RequireVerifiedEmail(conf.Accounts.RequireVerified)
+
+	health := controller.NewHealthController(version, conf, provider.Loaded)
+
+	docs, err := controller.NewDocsController()
+	if err != nil {
+		utils.Logger.Fatal(err)
+	}
+
 	//NEGORNI MIDDLEWARE
-	n := negroni.Classic()
+	n := negroni.New(negroni.NewRecovery(), negroni.NewStatic(http.Dir("public")))
 
+	tracing := middlleware.Tracing{Router: mux}
+
+	n.Use(negroni.HandlerFunc(tracing.Root))
 	n.Use(negroni.HandlerFunc(mdlw.Clear))
-	n.Use(negroni.HandlerFunc(mdlw.CORS))
-	n.Use(negroni.HandlerFunc(mdlw.Preflight))
-	n.Use(negroni.HandlerFunc(provider.JWT))
+	n.Use(middlleware.Traced("RequestID", mdlw.RequestID))
+	n.Use(middlleware.Traced("AccessLog", middlleware.AccessLog{Router: mux}.Handle))
+	n.Use(middlleware.Traced("Metrics", middlleware.Metrics{Router: mux}.Handle))
+	n.Use(middlleware.Traced("CORS", mdlw.CORS))
+	n.Use(middlleware.Traced("Preflight", mdlw.Preflight))
+
+	var limitStore middlleware.RateLimitStore = middlleware.NewMemoryRateLimitStore()
+	if conf.RateLimitStore == "db" {
+		limitStore = middlleware.DBRateLimitStore{}
+	}
+
+	limiter, err := middlleware.NewRateLimiter(conf.RateLimits, limitStore)
+	if err != nil {
+		utils.Logger.Fatal(err)
+	}
+
+	n.Use(middlleware.Traced("RateLimitByIP", limiter.ByIP))
+	n.Use(middlleware.Traced("JWT", provider.JWT))
+	n.Use(middlleware.Traced("RateLimitByUser", limiter.ByUser))
+
+	idempotencyTTL, err := time.ParseDuration(conf.IdempotencyTTL)
+	if err != nil {
+		utils.Logger.Fatal(err)
+	}
+	idempotency := middlleware.NewIdempotency(idempotencyTTL)
+	n.Use(middlleware.Traced("Idempotency", idempotency.Handle))
+
+	if conf.ValidateOpenAPI {
+		validator, err := middlleware.NewOpenAPIValidator(docs.Doc())
+		if err != nil {
+			utils.Logger.Fatal(err)
+		}
+		n.Use(middlleware.Traced("OpenAPIValidator", validator.Validate))
+	}
 
 	//USER OPTIONS
 	mux.POST("/register", users.Create)
 	mux.POST("/login", users.Login)
+	mux.POST("/token/refresh", users.Refresh)
 	mux.GET("/users", users.ListAll)
 	mux.PUT("/user/password1", users.UpdatePassword)
 	mux.PUT("/user/password2", users.UpdatePassword2)
@@ -51,9 +169,38 @@ func main() {
 	mux.GET("/user/:id", users.GetUser)
 	mux.GET("/logout", users.Logout)
 
+	//PASSWORD RESET
+	mux.POST("/password/forgot", accounts.ForgotPassword)
+	mux.POST("/password/reset", accounts.ResetPassword)
+
+	//EMAIL VERIFICATION
+	mux.POST("/email/verify", accounts.VerifyEmail)
+	mux.POST("/email/resend", accounts.ResendVerification)
+	mux.PUT("/user/email", accounts.UpdateEmail)
+
+	//TWO-FACTOR
+	mux.POST("/login/2fa", users.Login)
+	mux.POST("/me/2fa", accounts.EnrollTwoFactor)
+	mux.POST("/me/2fa/confirm", accounts.ConfirmTwoFactor)
+	mux.POST("/me/2fa/disable", accounts.DisableTwoFactor)
+
+	//SESSIONS
+	mux.GET("/me/sessions", users.MySessions)
+	mux.DELETE("/me/sessions", users.RevokeOtherSessions) //all but the current one
+	mux.DELETE("/me/sessions/:id", users.RevokeMySession)
+	mux.GET("/user/:id/sessions", users.UserSessions)
+	mux.DELETE("/user/:id/sessions", users.RevokeUserSessions)
+	mux.DELETE("/user/:id/sessions/:sid", users.RevokeUserSession)
+
+	//API TOKENS
+	mux.POST("/me/tokens", users.CreateAPIToken)
+	mux.GET("/me/tokens", users.ListAPITokens)
+	mux.DELETE("/me/tokens/:id", users.DeleteAPIToken)
+
 	//TODO
 	mux.POST("/todo", task.CreateToDo)
 	mux.POST("/task/:id", mdlw.CheckTask(task.CreateTask))
+	mux.POST("/batch", task.Batch)
 
 	mux.DELETE("/todo/:id", mdlw.CheckTodo(task.DeleteToDo))
 	mux.DELETE("/task/:id", mdlw.CheckTask(task.DeleteTask)) //task ID
@@ -70,8 +217,146 @@ func main() {
 	mux.GET("/tasks/active/:id", mdlw.CheckTask(task.ListAllActiveTasks))    //ToDo ID
 	mux.GET("/tasks/completed/:id", mdlw.CheckTask(task.ListCompletedTasks)) //ToDo ID
 
+	//TODO.TXT
+	mux.GET("/todo/:id/todotxt", mdlw.CheckTodo(task.ExportTodoTxt))
+	mux.POST("/todo/:id/import/todotxt", mdlw.CheckTodo(task.ImportTodoTxt))
+
+	//MARKDOWN
+	mux.GET("/todo/:id/markdown", mdlw.CheckTodo(task.ExportMarkdown))
+	mux.POST("/todo/:id/import/markdown", mdlw.CheckTodo(task.ImportMarkdown))
+
+	//CALENDAR
+	mux.POST("/todo/:id/feed", mdlw.CheckTodo(task.CreateFeed))
+	mux.DELETE("/todo/:id/feed", mdlw.CheckTodo(task.DeleteFeed))
+	mux.GET("/calendar/:token", task.Calendar)
+
+	//IMPORT / EXPORT
+	mux.GET("/export", task.Export)
+	mux.POST("/import", task.Import)
+
+	//GRAPHQL
+	mux.POST("/graphql", graph.Serve)
+
+	//DOCS
+	mux.GET("/openapi.json", docs.OpenAPI)
+	mux.GET("/.well-known/jwks.json", controller.NewKeysController(keys).JWKS)
+
+	//HEALTH
+	mux.GET("/healthz", health.Healthz)
+	mux.GET("/readyz", health.Readyz)
+	mux.GET("/debug/info", health.DebugInfo)
+
+	//METRICS
+	if conf.Metrics.Enabled {
+		metrics, err := controller.NewMetricsController(conf.Metrics)
+		if err != nil {
+			utils.Logger.Fatal(err)
+		}
+		mux.GET("/metrics", metrics.Serve)
+	}
+
+	//LIVE UPDATES
+	mux.GET("/events", events.Stream)
+	mux.GET("/ws", socket.Connect)
+
+	n.Use(negroni.HandlerFunc(tracing.Controller))
 	n.UseHandler(mux)
 
-	fmt.Println("Server started...")
-	log.Fatal(http.ListenAndServe(":8000", n))
+	//HTTP
+	srv, certs, err := utils.NewHTTPServer(conf.HTTP, middlleware.Streams([]string{"/events", "/ws"}, n))
+	if err != nil {
+		utils.Logger.Fatal(err)
+	}
+
+	//GRPC
+	var grpcOpts []grpc.ServerOption
+	if certs != nil {
+		//the same certificate as HTTP, reloaded along with it
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(&tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		})))
+	}
+
+	grpcServer := rpc.NewServer(provider.Allowed, limiter, grpcOpts...)
+
+	go func() {
+		lis, err := net.Listen("tcp", conf.GRPCAddress)
+		if err != nil {
+			utils.Logger.Fatal(err)
+		}
+
+		utils.Logger.Info("gRPC server started on " + conf.GRPCAddress)
+		if err := grpcServer.Serve(lis); err != nil {
+			utils.Logger.Fatal(err)
+		}
+	}()
+
+	go func() {
+		utils.Logger.Info("Server started on " + srv.Addr)
+
+		var err error
+		if certs != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+
+		if err != http.ErrServerClosed {
+			utils.Logger.Fatal(err)
+		}
+	}()
+
+	//SHUTDOWN
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+
+	<-stop
+	utils.Logger.Info("Shutting down...")
+
+	timeouts, _ := conf.HTTP.Timeouts()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Shutdown)
+	defer cancel()
+
+	//streams only end when their subscription does
+	utils.Events.Close()
+
+	err = srv.Shutdown(ctx)
+	if err != nil {
+		utils.Logger.WithError(err).Error("HTTP shutdown")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+
+	limiter.Close()
+	idempotency.Close()
+
+	if certs != nil {
+		certs.Close()
+	}
+
+	if db := utils.SQLAcc.GetSQLDB(); db != nil {
+		err = db.Close()
+		if err != nil {
+			utils.Logger.WithError(err).Error("closing DB")
+		}
+	}
+
+	err = shutdownTracer(ctx)
+	if err != nil {
+		utils.Logger.WithError(err).Error("flushing traces")
+	}
+
+	utils.Logger.Info("Server stopped")
 }
@@ -0,0 +1,397 @@
+package utils
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+//envPrefix starts the environment variables of every setting, TODOS_HTTP_ADDRESS sets http.address ...
+const envPrefix = "TODOS_"
+
+//Config is the whole configuration of an environment, see LoadConfig for where the values come from ...
+type Config struct {
+	Env             string          `yaml:"-"` //section of the file
+	File            string          `yaml:"-"`
+	DB              DBConf          `yaml:"db"`
+	JWT             JWTConf         `yaml:"jwt"`
+	RBAC            RBACConf        `yaml:"rbac"`
+	HTTP            HTTPConf        `yaml:"http"`
+	GRPCAddress     string          `yaml:"grpc_address"`
+	AutoMigrate     bool            `yaml:"auto_migrate"`     //applies pending migrations on start
+	ValidateOpenAPI bool            `yaml:"validate_openapi"` //dev mode, checks every request and response against docs/openapi.json
+	IdempotencyTTL  string          `yaml:"idempotency_ttl"`  //how long responses to an Idempotency-Key are replayed
+	RateLimitStore  string          `yaml:"rate_limit_store"` //memory, or db to share the limits between instances
+	RateLimits      []RateLimitRule `yaml:"rate_limits"`      //file only
+	Log             LogConf         `yaml:"log"`
+	Metrics         MetricsConf     `yaml:"metrics"`
+	Trace           TraceConf       `yaml:"trace"`
+	Mail            MailConf        `yaml:"mail"`
+	Accounts        AccountConf     `yaml:"accounts"`
+}
+
+//JWTConf points to the RSA keys of the tokens, see KeySet ...
+type JWTConf struct {
+	SigningKey string   `yaml:"signing_key"` //PEM private key signing new tokens
+	VerifyKeys []string `yaml:"verify_keys"` //PEM keys of earlier rotations, still accepted
+	AccessTTL  string   `yaml:"access_ttl"`  //lifetime of an access token
+	RefreshTTL string   `yaml:"refresh_ttl"` //a session ends when it isn't refreshed for this long
+}
+
+//AccountConf covers the mails sent to account owners ...
+type AccountConf struct {
+	ResetTTL        string `yaml:"reset_ttl"`              //how long a password reset token works
+	ResetURL        string `yaml:"reset_url"`              //page of the frontend, the token is appended; empty mails the bare token
+	VerifyTTL       string `yaml:"verify_ttl"`             //how long an email verification token works
+	VerifyURL       string `yaml:"verify_url"`             //like reset_url, for verification mails
+	RequireVerified bool   `yaml:"require_verified_email"` //refuses logins until the email is verified
+	TOTPIssuer      string `yaml:"totp_issuer"`            //name authenticator apps show for the account
+}
+
+//RBACConf points to the casbin model and policy ...
+type RBACConf struct {
+	Model  string `yaml:"model"`
+	Policy string `yaml:"policy"`
+}
+
+//redacted replaces secrets in Redacted
+const redacted = "[redacted]"
+
+//secret is a setting that can be read from a file instead of being written in the config ...
+type secret struct {
+	name  string
+	value *string
+	file  string
+}
+
+func (c *Config) secrets() []secret {
+	return []secret{
+		{"db.pass", &c.DB.Pass, c.DB.PassFile},
+		{"metrics.token", &c.Metrics.Token, c.Metrics.TokenFile},
+		{"mail.password", &c.Mail.Password, c.Mail.PasswordFile},
+	}
+}
+
+//Redacted returns a copy that is safe to show, secrets are masked ...
+func (c Config) Redacted() Config {
+	for _, s := range c.secrets() {
+		if *s.value != "" {
+			*s.value = redacted
+		}
+	}
+
+	return c
+}
+
+//HTTPConf configures the REST listener, TLS is on when CertFile and KeyFile are set ...
+type HTTPConf struct {
+	Address         string   `yaml:"address"`
+	ReadTimeout     string   `yaml:"read_timeout"`
+	WriteTimeout    string   `yaml:"write_timeout"` //lifted for /events and /ws
+	IdleTimeout     string   `yaml:"idle_timeout"`
+	ShutdownTimeout string   `yaml:"shutdown_timeout"` //how long in-flight requests may drain on SIGTERM
+	CertFile        string   `yaml:"cert_file"`        //reloaded when the file changes
+	KeyFile         string   `yaml:"key_file"`
+	HTTP2           bool     `yaml:"http2"`           //needs TLS
+	AllowedOrigins  []string `yaml:"allowed_origins"` //for CORS and WebSocket upgrades, "*" allows any
+	TrustedProxies  []string `yaml:"trusted_proxies"` //CIDRs or single addresses whose X-Forwarded-For is believed
+}
+
+//MetricsConf restricts /metrics to a bearer token or to client networks ...
+type MetricsConf struct {
+	Enabled         bool     `yaml:"enabled"`
+	Token           string   `yaml:"token"`
+	TokenFile       string   `yaml:"token_file"`       //read into Token
+	AllowedNetworks []string `yaml:"allowed_networks"` //CIDRs or single addresses
+}
+
+//RateLimitRule allows Limit requests per Period to the routes under Routes, counted per user or per ip ...
+type RateLimitRule struct {
+	Name      string         `yaml:"name"`
+	Routes    []string       `yaml:"routes"` //path prefixes, empty matches every route
+	Per       string         `yaml:"per"`    //user or ip
+	Limit     int            `yaml:"limit"`
+	Period    string         `yaml:"period"`
+	UserTypes map[string]int `yaml:"user_types"` //limit per user type, with the same period
+}
+
+//Problems lists what is wrong with the rule, name stands in for an empty Name ...
+func (r RateLimitRule) Problems(name string) []string {
+	var errs []string
+
+	period, err := time.ParseDuration(r.Period)
+
+	switch {
+	case r.Per != "user" && r.Per != "ip":
+		errs = append(errs, "rate limit "+name+": per must be user or ip")
+	case err != nil || period <= 0:
+		errs = append(errs, "rate limit "+name+": invalid period "+r.Period)
+	case r.Limit <= 0:
+		errs = append(errs, "rate limit "+name+": limit must be positive")
+	}
+
+	for userType, limit := range r.UserTypes {
+		if limit <= 0 {
+			errs = append(errs, "rate limit "+name+": limit for "+userType+" must be positive")
+		}
+	}
+
+	return errs
+}
+
+//DefaultConfig is used for everything the file, the environment and the flags leave out ...
+func DefaultConfig() Config {
+	return Config{
+		Env:  "dev",
+		File: "conf/conf.yaml",
+		JWT:  JWTConf{AccessTTL: "15m", RefreshTTL: "720h"},
+		RBAC: RBACConf{Model: "conf/rbac.conf", Policy: "conf/policy.csv"},
+		HTTP: HTTPConf{
+			Address:         ":8000",
+			ReadTimeout:     "15s",
+			WriteTimeout:    "30s",
+			IdleTimeout:     "2m",
+			ShutdownTimeout: "30s",
+			HTTP2:           true,
+			AllowedOrigins:  []string{"*"},
+		},
+		GRPCAddress:    ":9000",
+		AutoMigrate:    true,
+		IdempotencyTTL: "24h",
+		RateLimitStore: "memory",
+		RateLimits: []RateLimitRule{
+			{Name: "auth", Routes: []string{"/login", "/register", "/token/refresh", "/password", "/email"}, Per: "ip", Limit: 10, Period: "1m"},
+			{Name: "api", Per: "user", Limit: 300, Period: "1m", UserTypes: map[string]int{"admin": 1200}},
+		},
+		Log:      LogConf{Level: "info", Format: "text", MaxSize: 100, MaxBackups: 5, MaxAge: 30},
+		Metrics:  MetricsConf{Enabled: true, AllowedNetworks: []string{"127.0.0.1", "::1"}},
+		Trace:    TraceConf{Exporter: "none", SampleRatio: 1, ServiceName: "todos"},
+		Mail:     MailConf{Driver: "log", From: "todos@localhost", Port: "587"},
+		Accounts: AccountConf{ResetTTL: "1h", VerifyTTL: "48h", TOTPIssuer: "Todos"},
+	}
+}
+
+//LoadConfig reads the section of the YAML file, then the TODOS_ environment variables and then the
+//flags in args, each one overriding the one before. -env and -config (or TODOS_ENV and TODOS_CONFIG)
+//pick the section and the file. Every invalid or missing setting is reported in the one error ...
+func LoadConfig(args []string) (Config, error) {
+	conf := DefaultConfig()
+
+	flags := flag.NewFlagSet("todos", flag.ContinueOnError)
+	env := flags.String("env", "", "section of the config file, or "+envPrefix+"ENV (default dev)")
+	file := flags.String("config", "", "path of the config file, or "+envPrefix+"CONFIG (default conf/conf.yaml)")
+
+	given := make(map[string]string)
+	for _, s := range settings(reflect.ValueOf(&conf).Elem(), "") {
+		flags.Var(flagValue{path: s.path, given: given, boolean: s.value.Kind() == reflect.Bool}, s.path, "overrides "+s.env)
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return conf, err
+	}
+
+	conf.Env = firstOf(*env, os.Getenv(envPrefix+"ENV"), conf.Env)
+
+	explicit := firstOf(*file, os.Getenv(envPrefix+"CONFIG"))
+	conf.File = firstOf(explicit, conf.File)
+
+	var errs []string
+
+	err = conf.readFile()
+	if err != nil && (explicit != "" || !os.IsNotExist(err)) {
+		errs = append(errs, conf.File+": "+err.Error())
+	}
+
+	for _, s := range settings(reflect.ValueOf(&conf).Elem(), "") {
+		if raw, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, s.env+": "+err.Error())
+			}
+		}
+	}
+
+	for _, s := range settings(reflect.ValueOf(&conf).Elem(), "") {
+		if raw, ok := given[s.path]; ok {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, "-"+s.path+": "+err.Error())
+			}
+		}
+	}
+
+	for _, s := range conf.secrets() {
+		if s.file == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(s.file)
+		if err != nil {
+			errs = append(errs, s.name+"_file: "+err.Error())
+			continue
+		}
+		*s.value = strings.TrimRight(string(data), "\r\n")
+	}
+
+	errs = append(errs, conf.problems()...)
+
+	if len(errs) > 0 {
+		return conf, errors.New("invalid configuration: " + strings.Join(errs, "; "))
+	}
+
+	return conf, nil
+}
+
+//readFile decodes the section of Env over the values set so far ...
+func (c *Config) readFile() error {
+	data, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		return err
+	}
+
+	var cs map[string]interface{}
+
+	err = yaml.Unmarshal(data, &cs)
+	if err != nil {
+		return err
+	}
+
+	if cs[c.Env] == nil {
+		return errors.New("no " + c.Env + " section")
+	}
+
+	//decoding the section again over the defaults keeps the values it leaves out
+	section, err := yaml.Marshal(cs[c.Env])
+	if err != nil {
+		return err
+	}
+
+	err = yaml.Unmarshal(section, c)
+	if err != nil {
+		return err
+	}
+
+	//sections written before db: existed keep the DB settings at the top
+	if keys, ok := cs[c.Env].(map[interface{}]interface{}); ok && keys["db"] == nil {
+		err = yaml.Unmarshal(section, &c.DB)
+	}
+
+	return err
+}
+
+//problems checks the settings that would otherwise only fail once they are used ...
+func (c *Config) problems() []string {
+	var errs []string
+
+	missing := func(name, value string) {
+		if value == "" {
+			errs = append(errs, name+" is missing")
+		}
+	}
+
+	missing("db.name", c.DB.Name)
+	missing("db.user", c.DB.User)
+	missing("db.address", c.DB.Address)
+	missing("db.port", c.DB.Port)
+	missing("jwt.signing_key", c.JWT.SigningKey)
+	missing("http.address", c.HTTP.Address)
+	missing("grpc_address", c.GRPCAddress)
+	missing("accounts.totp_issuer", c.Accounts.TOTPIssuer)
+
+	if c.JWT.SigningKey != "" {
+		if _, err := LoadKeySet(c.JWT); err != nil {
+			errs = append(errs, "jwt: "+err.Error())
+		}
+	}
+
+	for _, path := range []string{c.RBAC.Model, c.RBAC.Policy} {
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, "rbac: "+err.Error())
+		}
+	}
+
+	if _, err := c.HTTP.Timeouts(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if (c.HTTP.CertFile == "") != (c.HTTP.KeyFile == "") {
+		errs = append(errs, "http: cert_file and key_file must be set together")
+	}
+
+	for _, ttl := range [][2]string{{"jwt.access_ttl", c.JWT.AccessTTL}, {"jwt.refresh_ttl", c.JWT.RefreshTTL},
+		{"accounts.reset_ttl", c.Accounts.ResetTTL}, {"accounts.verify_ttl", c.Accounts.VerifyTTL}} {
+		if d, err := time.ParseDuration(ttl[1]); err != nil || d <= 0 {
+			errs = append(errs, ttl[0]+": invalid duration "+ttl[1])
+		}
+	}
+
+	if ttl, err := time.ParseDuration(c.IdempotencyTTL); err != nil || ttl <= 0 {
+		errs = append(errs, "idempotency_ttl: invalid duration "+c.IdempotencyTTL)
+	}
+
+	if c.RateLimitStore != "memory" && c.RateLimitStore != "db" {
+		errs = append(errs, "rate_limit_store must be memory or db")
+	}
+	for i, rule := range c.RateLimits {
+		name := rule.Name
+		if name == "" {
+			name = "#" + strconv.Itoa(i)
+		}
+		errs = append(errs, rule.Problems(name)...)
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, "log.level: "+err.Error())
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" && c.Log.Format != "" {
+		errs = append(errs, "log.format must be text or json")
+	}
+
+	for _, network := range c.Metrics.AllowedNetworks {
+		if _, err := ParseNetworks([]string{network}); err != nil {
+			errs = append(errs, "metrics.allowed_networks: invalid network "+network)
+		}
+	}
+	for _, network := range c.HTTP.TrustedProxies {
+		if _, err := ParseNetworks([]string{network}); err != nil {
+			errs = append(errs, "http.trusted_proxies: invalid network "+network)
+		}
+	}
+
+	switch c.Trace.Exporter {
+	case "none", "", "stdout", "otlp-grpc", "otlp-http":
+	default:
+		errs = append(errs, "trace.exporter must be none, stdout, otlp-grpc or otlp-http")
+	}
+	if c.Trace.SampleRatio < 0 || c.Trace.SampleRatio > 1 {
+		errs = append(errs, "trace.sample_ratio must be between 0 and 1")
+	}
+
+	switch c.Mail.Driver {
+	case "log", "":
+	case "smtp":
+		missing("mail.from", c.Mail.From)
+		missing("mail.host", c.Mail.Host)
+		missing("mail.port", c.Mail.Port)
+	default:
+		errs = append(errs, "mail.driver must be log or smtp")
+	}
+
+	return errs
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//writeFile puts data into dir and returns its path
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+//writeKey writes a new RSA private key as PEM
+func writeKey(t *testing.T, dir, name string) (string, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+
+	return writeFile(t, dir, name, string(pem.EncodeToMemory(block))), key
+}
+
+//testConfig writes a complete test section and the files it points to, the returned args load it
+func testConfig(t *testing.T) (string, []string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	key, _ := writeKey(t, dir, "key.pem")
+
+	file := writeFile(t, dir, "conf.yaml", `test:
+  db:
+    name: todos
+    user: todos
+    address: db.local
+    port: "3306"
+  jwt:
+    signing_key: `+key+`
+  rbac:
+    model: `+writeFile(t, dir, "rbac.conf", "")+`
+    policy: `+writeFile(t, dir, "policy.csv", "")+`
+  http:
+    address: ":1000"
+    read_timeout: 5s
+  grpc_address: ":2000"
+  accounts:
+    totp_issuer: Todos
+`)
+
+	return dir, []string{"-config", file, "-env", "test"}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		flag    string
+		address string
+	}{
+		{"file over default", "", "", ":1000"},
+		{"env over file", ":2001", "", ":2001"},
+		{"flag over file", "", ":3001", ":3001"},
+		{"flag over env", ":2001", ":3001", ":3001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, args := testConfig(t)
+
+			if tt.env != "" {
+				t.Setenv("TODOS_HTTP_ADDRESS", tt.env)
+			}
+			if tt.flag != "" {
+				args = append(args, "-http.address", tt.flag)
+			}
+
+			conf, err := LoadConfig(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if conf.HTTP.Address != tt.address {
+				t.Errorf("http.address = %q, want %q", conf.HTTP.Address, tt.address)
+			}
+			//the rest of the section and the defaults it leaves out stay
+			if conf.HTTP.ReadTimeout != "5s" || conf.HTTP.WriteTimeout != "30s" {
+				t.Errorf("timeouts = %q, %q, want 5s from the file and 30s by default", conf.HTTP.ReadTimeout, conf.HTTP.WriteTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadConfigLists(t *testing.T) {
+	_, args := testConfig(t)
+	t.Setenv("TODOS_HTTP_ALLOWED_ORIGINS", "https://a.example, https://b.example")
+
+	conf, err := LoadConfig(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(conf.HTTP.AllowedOrigins, " "); got != "https://a.example https://b.example" {
+		t.Errorf("http.allowed_origins = %q", got)
+	}
+}
+
+func TestLoadConfigSecretFiles(t *testing.T) {
+	dir, args := testConfig(t)
+
+	t.Setenv("TODOS_DB_PASS", "from env")
+	args = append(args,
+		"-db.pass_file", writeFile(t, dir, "db_pass", "from file\n"),
+		"-metrics.token_file", writeFile(t, dir, "token", "scrape\r\n"),
+	)
+
+	conf, err := LoadConfig(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.DB.Pass != "from file" {
+		t.Errorf("db.pass = %q, want the file without its line end", conf.DB.Pass)
+	}
+	if conf.Metrics.Token != "scrape" {
+		t.Errorf("metrics.token = %q", conf.Metrics.Token)
+	}
+	if conf.Redacted().DB.Pass != redacted {
+		t.Error("Redacted kept db.pass")
+	}
+
+	_, err = LoadConfig(append(args, "-mail.password_file", filepath.Join(dir, "missing")))
+	if err == nil || !strings.Contains(err.Error(), "mail.password_file: ") {
+		t.Errorf("missing secret file gave %v", err)
+	}
+}
+
+func TestLoadConfigReportsEveryProblem(t *testing.T) {
+	_, args := testConfig(t)
+
+	t.Setenv("TODOS_HTTP_HTTP2", "maybe")
+	args = append(args,
+		"-db.name=",
+		"-http.read_timeout", "soon",
+		"-log.level", "loud",
+		"-rate_limit_store", "redis",
+		"-http.trusted_proxies", "10.0.0.0/8,proxy",
+	)
+
+	_, err := LoadConfig(args)
+	if err == nil {
+		t.Fatal("LoadConfig accepted an invalid configuration")
+	}
+
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "invalid configuration: ") {
+		t.Errorf("error %q", msg)
+	}
+
+	for _, want := range []string{
+		"TODOS_HTTP_HTTP2: invalid bool",
+		"db.name is missing",
+		"http read_timeout: invalid duration soon",
+		"log.level: ",
+		"rate_limit_store must be memory or db",
+		"http.trusted_proxies: invalid network proxy",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error misses %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestLoadConfigMissingSection(t *testing.T) {
+	_, args := testConfig(t)
+
+	_, err := LoadConfig(append(args, "-env", "prod"))
+	if err == nil || !strings.Contains(err.Error(), "no prod section") {
+		t.Errorf("LoadConfig = %v, want the missing section", err)
+	}
+}
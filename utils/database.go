@@ -1,13 +1,9 @@
 package utils
 
 import (
-	"fmt"
-	"io/ioutil"
-
 	//Needs to be imported, or it will cause an error .
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
-	"gopkg.in/yaml.v2"
 )
 
 //DBAccess ...
@@ -20,45 +16,26 @@ var SQLAcc DBAccess
 
 //DBConf ...
 type DBConf struct {
-	Name    string `yaml:"name"`
-	User    string `yaml:"user"`
-	Pass    string `yaml:"pass"`
-	Address string `yaml:"address"`
-	Port    string `yaml:"port"`
+	Name     string `yaml:"name"`
+	User     string `yaml:"user"`
+	Pass     string `yaml:"pass"`
+	PassFile string `yaml:"pass_file"` //read into Pass
+	Address  string `yaml:"address"`
+	Port     string `yaml:"port"`
 }
 
-//Configs ...
-type Configs map[string]DBConf
-
-//GetSQLDB ...
-func GetSQLDB(env, path string) error {
-
-	data, err := ioutil.ReadFile(path)
-
-	if err != nil {
-		fmt.Println("error opening configuration", err.Error())
-	}
-
-	var cs Configs
-
-	err = yaml.Unmarshal(data, &cs)
-	dbconf := cs[env]
-
-	dbDriver := "mysql"
-	dbUser := dbconf.User
-	dbPass := dbconf.Pass
-	dbName := dbconf.Name
-	dbAddress := dbconf.Address
-	dbPort := dbconf.Port
-	db, err := sqlx.Connect(dbDriver, dbUser+":"+dbPass+"@"+"tcp("+dbAddress+":"+dbPort+")/"+dbName)
+//OpenDB opens the pool, the error tells if the DB is reachable ...
+func OpenDB(conf DBConf) error {
+	db, err := sqlx.Open("mysql", conf.User+":"+conf.Pass+"@"+"tcp("+conf.Address+":"+conf.Port+")/"+conf.Name)
 
 	if err != nil {
 		return err
 	}
 
+	//kept even when the ping fails, the pool reconnects once the DB is back and /readyz tells when
 	SQLAcc.SQLDB = db
 
-	return err
+	return db.Ping()
 }
 
 //GetSQLDB method ...
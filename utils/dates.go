@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+//dateLayouts are the formats clients use for Task dates ...
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	time.RFC3339,
+	"02.01.2006",
+	"02.01.2006 15:04",
+}
+
+//ParseDate parses a Task date, hasTime is false for date-only values ...
+func ParseDate(value string) (t time.Time, hasTime bool, ok bool) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range dateLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, strings.Contains(layout, "15"), true
+		}
+	}
+
+	return time.Time{}, false, false
+}
+
+//HasZone tells if a Task date carries its own offset, as RFC 3339 values do ...
+func HasZone(value string) bool {
+	_, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+
+	return err == nil
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+//Event is a single change pushed to live subscribers ...
+type Event struct {
+	ID     int64       `json:"id"`
+	Type   string      `json:"type"`   //e.g. task.created, todo.deleted
+	ToDoID int         `json:"todoID"` //list the change belongs to
+	UserID int         `json:"userID"` //owner of the list
+	Time   time.Time   `json:"time"`
+	Data   interface{} `json:"data"`
+}
+
+//Subscription receives events accepted by its filter ...
+type Subscription struct {
+	C      chan Event
+	filter func(Event) bool
+}
+
+//EventHub fans events out to subscribers and keeps a bounded replay buffer ...
+type EventHub struct {
+	mu     sync.Mutex
+	seq    int64
+	buffer []Event
+	size   int
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+//Events is the hub used by the controllers ...
+var Events = NewEventHub(256)
+
+//NewEventHub creates a hub which remembers the last size events ...
+func NewEventHub(size int) *EventHub {
+	return &EventHub{
+		size: size,
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+//Publish assigns the next event ID and delivers the event ...
+func (h *EventHub) Publish(e Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	e.ID = h.seq
+	e.Time = time.Now()
+
+	h.buffer = append(h.buffer, e)
+	if len(h.buffer) > h.size {
+		h.buffer = h.buffer[len(h.buffer)-h.size:]
+	}
+
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+
+		select {
+		case sub.C <- e:
+		default:
+			//slow consumer, drop it so the client reconnects with Last-Event-ID
+			delete(h.subs, sub)
+			close(sub.C)
+		}
+	}
+
+	return e
+}
+
+//Subscribe registers a new subscriber, filter may be nil ...
+func (h *EventHub) Subscribe(filter func(Event) bool) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription{C: make(chan Event, 64), filter: filter}
+	if h.closed {
+		close(sub.C)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+
+	return sub
+}
+
+//Unsubscribe removes the subscriber and closes its channel ...
+func (h *EventHub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+//Close ends every subscription, streams see their channel closed and return ...
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+//Closed tells if Close was called, a closed subscription then means shutdown and not a slow consumer ...
+func (h *EventHub) Closed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.closed
+}
+
+//Since returns buffered events with ID greater than lastID ...
+func (h *EventHub) Since(lastID int64) []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var events []Event
+	for _, e := range h.buffer {
+		if e.ID > lastID {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
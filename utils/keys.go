@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//SigningKey is an RSA key named by the thumbprint of its public half, Private is nil for keys that only verify ...
+type SigningKey struct {
+	ID      string
+	Private *rsa.PrivateKey
+	Public  *rsa.PublicKey
+}
+
+//KeySet signs new tokens with the active key and accepts tokens of every key it holds,
+//so older keys keep verifying until the tokens they signed have expired ...
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+//JWK is the public part of a key as published in the JWKS document ...
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+//JWKS ...
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+//LoadKeySet reads the private signing key and the keys still accepted during a rotation, all PEM files ...
+func LoadKeySet(conf JWTConf) (*KeySet, error) {
+	data, err := ioutil.ReadFile(conf.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, errors.New(conf.SigningKey + ": " + err.Error())
+	}
+
+	set := &KeySet{keys: make(map[string]*SigningKey)}
+	set.active = set.add(&private.PublicKey, private)
+
+	for _, path := range conf.VerifyKeys {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		//a retired private key works as well as its public key
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			set.add(&private.PublicKey, nil)
+			continue
+		}
+
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+		set.add(public, nil)
+	}
+
+	return set, nil
+}
+
+func (k *KeySet) add(public *rsa.PublicKey, private *rsa.PrivateKey) *SigningKey {
+	key := &SigningKey{ID: thumbprint(public), Private: private, Public: public}
+	if _, ok := k.keys[key.ID]; !ok {
+		k.keys[key.ID] = key
+	}
+
+	return key
+}
+
+//Sign signs the claims with the active key and names it in the kid header ...
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = k.active.ID
+
+	return token.SignedString(k.active.Private)
+}
+
+//Parse checks the signature against the key named by kid, tokens without one can't be verified ...
+func (k *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, errors.New("Client is not using the correct algorithm")
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, errors.New("Unknown signing key")
+		}
+
+		return key.Public, nil
+	})
+}
+
+//JWKS lists the public keys, the active one first ...
+func (k *KeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.active.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	set := JWKS{Keys: []JWK{}}
+	for _, id := range append([]string{k.active.ID}, ids...) {
+		set.Keys = append(set.Keys, jwk(k.keys[id]))
+	}
+
+	return set
+}
+
+func jwk(key *SigningKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: key.ID,
+		N:   base64.RawURLEncoding.EncodeToString(key.Public.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.Public.E)).Bytes()),
+	}
+}
+
+//thumbprint is the RFC 7638 thumbprint, it changes with the key so rotations need no naming ...
+func thumbprint(public *rsa.PublicKey) string {
+	key := jwk(&SigningKey{Public: public})
+
+	//the members in lexicographic order, as the RFC requires
+	data, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{key.E, key.Kty, key.N})
+
+	sum := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//writePublicKey writes the public half of key as PEM
+func writePublicKey(t *testing.T, dir, name string, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writeFile(t, dir, name, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+}
+
+//rotatedKeys loads a set signing with a new key that still accepts a retired private key and a retired public key
+func rotatedKeys(t *testing.T) (current, retired, retiredPublic *KeySet) {
+	t.Helper()
+
+	dir := t.TempDir()
+	active, _ := writeKey(t, dir, "active.pem")
+	old, _ := writeKey(t, dir, "old.pem")
+	older, olderKey := writeKey(t, dir, "older.pem")
+
+	var err error
+
+	current, err = LoadKeySet(JWTConf{SigningKey: active, VerifyKeys: []string{old, writePublicKey(t, dir, "older.pub", olderKey)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retired, err = LoadKeySet(JWTConf{SigningKey: old}); err != nil {
+		t.Fatal(err)
+	}
+	if retiredPublic, err = LoadKeySet(JWTConf{SigningKey: older}); err != nil {
+		t.Fatal(err)
+	}
+
+	return current, retired, retiredPublic
+}
+
+func TestKeySetRotation(t *testing.T) {
+	current, retired, retiredPublic := rotatedKeys(t)
+	claims := jwt.MapClaims{"sub": "1"}
+
+	for name, signer := range map[string]*KeySet{"active": current, "retired private": retired, "retired public": retiredPublic} {
+		token, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := current.Parse(token)
+		if err != nil || !parsed.Valid {
+			t.Errorf("token of the %s key: %v", name, err)
+		}
+	}
+
+	//the retired sets don't know the new key
+	token, _ := current.Sign(claims)
+	if _, err := retired.Parse(token); err == nil {
+		t.Error("a set without the active key accepted its token")
+	}
+}
+
+func TestKeySetRejects(t *testing.T) {
+	current, _, _ := rotatedKeys(t)
+	claims := jwt.MapClaims{"sub": "1"}
+	active := current.active
+
+	sign := func(method jwt.SigningMethod, kid, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signed
+	}
+
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(active.Public)})
+	_, stranger, _ := rotatedKeys(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"missing kid", sign(jwt.SigningMethodRS256, nil, active.Private)},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "not-a-key", active.Private)},
+		{"kid of another key", sign(jwt.SigningMethodRS256, current.active.ID, stranger.active.Private)},
+		{"kid is not a string", sign(jwt.SigningMethodRS256, 5, active.Private)},
+		{"RS512", sign(jwt.SigningMethodRS512, current.active.ID, active.Private)},
+		{"HS256 with the public key as secret", sign(jwt.SigningMethodHS256, current.active.ID, publicPEM)},
+		{"none", sign(jwt.SigningMethodNone, current.active.ID, jwt.UnsafeAllowNoneSignatureType)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := current.Parse(tt.token); err == nil {
+				t.Error("Parse accepted the token")
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	current, retired, retiredPublic := rotatedKeys(t)
+
+	set := current.JWKS()
+	if len(set.Keys) != 3 {
+		t.Fatalf("JWKS has %d keys, want 3", len(set.Keys))
+	}
+
+	if set.Keys[0].Kid != current.active.ID {
+		t.Errorf("first key %s, want the active %s", set.Keys[0].Kid, current.active.ID)
+	}
+
+	rest := []string{retired.active.ID, retiredPublic.active.ID}
+	sort.Strings(rest)
+	if set.Keys[1].Kid != rest[0] || set.Keys[2].Kid != rest[1] {
+		t.Errorf("retired keys %s, %s, want them sorted %v", set.Keys[1].Kid, set.Keys[2].Kid, rest)
+	}
+
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || key.Use != "sig" || key.Alg != "RS256" || key.N == "" || key.E != "AQAB" {
+			t.Errorf("key %+v", key)
+		}
+	}
+}
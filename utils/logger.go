@@ -1,19 +1,81 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
 	"path/filepath"
 	"runtime"
 
+	"github.com/gorilla/context"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 //Logger ...
 var (
-	Logger *logrus.Logger
+	Logger = logrus.New()
 	lvl    string
 )
 
+//LogConf ...
+type LogConf struct {
+	Level      string `yaml:"level"`  //debug, info, warn or error
+	Format     string `yaml:"format"` //text or json
+	File       string `yaml:"file"`   //empty logs to stdout
+	MaxSize    int    `yaml:"max_size"`
+	MaxBackups int    `yaml:"max_backups"`
+	MaxAge     int    `yaml:"max_age"` //days to keep rotated files
+	Compress   bool   `yaml:"compress"`
+}
+
+//InitLogger configures Logger for env, the file is rotated after MaxSize megabytes ...
+func InitLogger(env string, conf LogConf) error {
+	level, err := logrus.ParseLevel(conf.Level)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(level)
+
+	switch conf.Format {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case "text", "":
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return errors.New("Log format must be text or json")
+	}
+
+	if conf.File != "" {
+		logger.SetOutput(&lumberjack.Logger{
+			Filename:   conf.File,
+			MaxSize:    conf.MaxSize,
+			MaxBackups: conf.MaxBackups,
+			MaxAge:     conf.MaxAge,
+			Compress:   conf.Compress,
+		})
+	} else {
+		logger.SetOutput(os.Stdout)
+	}
+
+	Logger = logger
+	lvl = env
+
+	return nil
+}
+
+//RequestLogger returns the logger carrying the request ID, set by Middlleware.RequestID ...
+func RequestLogger(r *http.Request) *logrus.Entry {
+	if entry, ok := context.Get(r, "logger").(*logrus.Entry); ok {
+		return entry
+	}
+
+	return logrus.NewEntry(Logger)
+}
+
 //LogError ...
 func LogError(details string, err error) {
 	if lvl != "prod" {
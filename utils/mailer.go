@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//MailConf picks how mail goes out, log only writes it to the log for development ...
+type MailConf struct {
+	Driver       string `yaml:"driver"` //log or smtp
+	From         string `yaml:"from"`
+	Host         string `yaml:"host"`
+	Port         string `yaml:"port"`
+	Username     string `yaml:"username"` //empty sends without authentication
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"` //read into Password
+}
+
+//Message is a plain text mail ...
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+//Mailer delivers messages, see LogMailer and SMTPMailer ...
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+//Mail is the mailer used by the controllers, set by InitMailer ...
+var Mail Mailer = LogMailer{}
+
+//InitMailer sets Mail for the driver of conf ...
+func InitMailer(conf MailConf) error {
+	switch conf.Driver {
+	case "log", "":
+		Mail = LogMailer{}
+	case "smtp":
+		Mail = SMTPMailer{Conf: conf}
+	default:
+		return errors.New("Mail driver must be log or smtp")
+	}
+
+	return nil
+}
+
+//SendMail delivers msg in the background, callers answer the same whether or not a mail goes out ...
+func SendMail(ctx context.Context, msg Message) {
+	//the request may end before the mail is sent, only its trace is kept
+	ctx = trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+
+	go func() {
+		ctx, span := Tracer().Start(ctx, "mail "+msg.Subject)
+		defer span.End()
+
+		err := Mail.Send(ctx, msg)
+		if err != nil {
+			span.RecordError(err)
+			Logger.WithError(err).WithField("subject", msg.Subject).Error("sending mail")
+		}
+	}()
+}
+
+//LogMailer writes messages to the log instead of sending them ...
+type LogMailer struct{}
+
+//Send ...
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	Logger.WithFields(logrus.Fields{"to": msg.To, "subject": msg.Subject}).Info(msg.Body)
+
+	return nil
+}
+
+//SMTPMailer sends through an SMTP server, with STARTTLS when the server offers it ...
+type SMTPMailer struct {
+	Conf MailConf
+}
+
+//Send ...
+func (m SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Conf.Username != "" {
+		auth = smtp.PlainAuth("", m.Conf.Username, m.Conf.Password, m.Conf.Host)
+	}
+
+	header := []string{
+		"From: " + m.Conf.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+
+	body := strings.Join(header, "\r\n") + "\r\n\r\n" + strings.Replace(msg.Body, "\n", "\r\n", -1)
+
+	return smtp.SendMail(net.JoinHostPort(m.Conf.Host, m.Conf.Port), auth, m.Conf.From, []string{msg.To}, []byte(body))
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	//HTTPRequests counts answered requests by route template ...
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "todos_http_requests_total",
+		Help: "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	//HTTPDuration ...
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "todos_http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	//DBQueryDuration is observed by the model functions through ObserveQuery ...
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "todos_db_query_duration_seconds",
+		Help:    "Time spent in the database by model function.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"function"})
+
+	//LoginAttempts ...
+	LoginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "todos_login_attempts_total",
+		Help: "Logins by result, success or failure.",
+	}, []string{"result"})
+
+	//RBACDenied counts requests refused by the RBAC rules, by first path segment ...
+	RBACDenied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "todos_rbac_denied_total",
+		Help: "Requests denied by the RBAC policy by action and mode.",
+	}, []string{"action", "mode"})
+)
+
+//ObserveQuery times a model function and opens its span, call the returned func when it is done ...
+func ObserveQuery(ctx context.Context, function string) (context.Context, func()) {
+	start := time.Now()
+
+	ctx, span := Tracer().Start(ctx, "model."+function, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "mysql"), attribute.String("code.function", function)))
+
+	return ctx, func() {
+		DBQueryDuration.WithLabelValues(function).Observe(time.Since(start).Seconds())
+		span.End()
+	}
+}
+
+//RegisterDBStats exports the connection pool statistics of db ...
+func RegisterDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "todos"))
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+//RateLimit allows Burst requests at once and refills Burst tokens every Period ...
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+//perSecond is the refill rate of the bucket ...
+func (l RateLimit) perSecond() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+//TokenBucket is the state of one limited key, Updated is in unix nanoseconds ...
+type TokenBucket struct {
+	Tokens  float64 `db:"tokens"`
+	Updated int64   `db:"updated"`
+}
+
+//RateLimitResult is what Take decided, with the numbers for the RateLimit-* headers ...
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration //until the next token, only when not allowed
+	Reset      time.Duration //until the bucket is full again
+}
+
+//Take refills the bucket for the time since the last call and takes one token if there is one ...
+func (b *TokenBucket) Take(limit RateLimit, now time.Time) RateLimitResult {
+	rate := limit.perSecond()
+	burst := float64(limit.Burst)
+
+	if b.Updated == 0 {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(time.Unix(0, b.Updated)).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*rate)
+	}
+	b.Updated = now.UnixNano()
+
+	result := RateLimitResult{Limit: limit.Burst}
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.Tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(b.Tokens)
+	result.Reset = time.Duration((burst - b.Tokens) / rate * float64(time.Second))
+
+	return result
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	limit := RateLimit{Burst: 3, Period: 3 * time.Second} //a token a second
+
+	type take struct {
+		at         time.Duration //since start
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name:  "new bucket starts full",
+			takes: []take{{0, true, 2, 0}},
+		},
+		{
+			name: "burst then empty",
+			takes: []take{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+			},
+		},
+		{
+			name: "refills with time",
+			takes: []take{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+				{time.Second, true, 0, 0},
+				{3 * time.Second, true, 1, 0},
+			},
+		},
+		{
+			name: "refill stops at the burst",
+			takes: []take{
+				{0, true, 2, 0},
+				{time.Hour, true, 2, 0},
+			},
+		},
+		{
+			name: "clock going back adds nothing",
+			takes: []take{
+				{time.Minute, true, 2, 0},
+				{0, true, 1, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bucket TokenBucket
+
+			for i, step := range tt.takes {
+				result := bucket.Take(limit, start.Add(step.at))
+
+				if result.Allowed != step.allowed || result.Remaining != step.remaining || result.RetryAfter != step.retryAfter {
+					t.Fatalf("take %d at %v = allowed %v remaining %d retry %v, want %v %d %v", i, step.at,
+						result.Allowed, result.Remaining, result.RetryAfter, step.allowed, step.remaining, step.retryAfter)
+				}
+				if result.Limit != limit.Burst {
+					t.Errorf("take %d limit = %d, want %d", i, result.Limit, limit.Burst)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenBucketReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	bucket := TokenBucket{}
+
+	result := bucket.Take(RateLimit{Burst: 10, Period: time.Minute}, now)
+
+	//one token short, at one token per 6s
+	if result.Reset != 6*time.Second {
+		t.Errorf("Reset = %v, want 6s", result.Reset)
+	}
+}
@@ -20,8 +20,7 @@ type RendererCtrl struct {
 
 // Render ->
 func (rend *RendererCtrl) Render(res http.ResponseWriter, status int, v interface{}) {
-	res.Header().Set("Access-Control-Allow-Origin", "*")
-
+	//Access-Control-Allow-Origin is left to Middlleware.CORS, which knows the allowed origins
 	if rend == nil {
 		log.Println("REND ctrlr is NIL")
 		return
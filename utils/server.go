@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+//ServerTimeouts are the parsed durations of HTTPConf ...
+type ServerTimeouts struct {
+	Read     time.Duration
+	Write    time.Duration
+	Idle     time.Duration
+	Shutdown time.Duration
+}
+
+//Timeouts parses the durations and collects every invalid one in the error ...
+func (c HTTPConf) Timeouts() (ServerTimeouts, error) {
+	var timeouts ServerTimeouts
+	var errs []string
+
+	for _, field := range []struct {
+		name  string
+		value string
+		to    *time.Duration
+	}{
+		{"read_timeout", c.ReadTimeout, &timeouts.Read},
+		{"write_timeout", c.WriteTimeout, &timeouts.Write},
+		{"idle_timeout", c.IdleTimeout, &timeouts.Idle},
+		{"shutdown_timeout", c.ShutdownTimeout, &timeouts.Shutdown},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(field.value)
+		if err != nil || d < 0 {
+			errs = append(errs, "http "+field.name+": invalid duration "+field.value)
+			continue
+		}
+		*field.to = d
+	}
+
+	if len(errs) > 0 {
+		return timeouts, errors.New(strings.Join(errs, "; "))
+	}
+
+	return timeouts, nil
+}
+
+//ParseNetworks parses CIDRs, a single address stands for a network of its own ...
+func ParseNetworks(list []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+
+	for _, cidr := range list {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+//NewHTTPServer builds the server for handler from conf, with TLS the returned reloader
+//has to be closed once the server is shut down ...
+func NewHTTPServer(conf HTTPConf, handler http.Handler) (*http.Server, *CertReloader, error) {
+	timeouts, err := conf.Timeouts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if (conf.CertFile == "") != (conf.KeyFile == "") {
+		return nil, nil, errors.New("http: cert_file and key_file must be set together")
+	}
+
+	srv := &http.Server{
+		Addr:              conf.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.Read,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+		ErrorLog:          log.New(Logger.WriterLevel(logrus.WarnLevel), "http: ", 0),
+	}
+
+	if conf.CertFile == "" {
+		return srv, nil, nil
+	}
+
+	certs, err := NewCertReloader(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+
+	if !conf.HTTP2 {
+		//a non-nil empty map keeps net/http from enabling HTTP/2
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+
+	return srv, certs, nil
+}
+
+//CertReloader serves the certificate of CertFile and KeyFile and loads it again when either changes ...
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+//NewCertReloader loads the key pair and watches the directories holding it ...
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	c := &CertReloader{certFile: certFile, keyFile: keyFile, done: make(chan struct{})}
+
+	err := c.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	c.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	//the directories and not the files, renames and symlink swaps replace the watched inode
+	for _, dir := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+		err = c.watcher.Add(dir)
+		if err != nil {
+			c.watcher.Close()
+			return nil, err
+		}
+	}
+
+	go c.watch()
+
+	return c, nil
+}
+
+//GetCertificate is the tls.Config callback ...
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cert, nil
+}
+
+//Close stops watching the files ...
+func (c *CertReloader) Close() error {
+	err := c.watcher.Close()
+	<-c.done
+
+	return err
+}
+
+func (c *CertReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+
+	return nil
+}
+
+//watches tells if a change of name may replace the key pair, ..data is swapped by Kubernetes secret mounts
+func (c *CertReloader) watches(name string) bool {
+	base := filepath.Base(name)
+
+	return base == filepath.Base(c.certFile) || base == filepath.Base(c.keyFile) || base == "..data"
+}
+
+func (c *CertReloader) watch() {
+	defer close(c.done)
+
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			if !c.watches(event.Name) {
+				continue
+			}
+
+			//the cert and the key are rarely written at once, a failed load keeps the old pair until the next event
+			if err := c.reload(); err != nil {
+				Logger.WithError(err).Warn("reloading TLS certificate")
+				continue
+			}
+			Logger.Info("TLS certificate reloaded")
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			Logger.WithError(err).Error("watching TLS certificate")
+		}
+	}
+}
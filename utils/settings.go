@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//setting is a scalar of Config that the environment and the flags can set ...
+type setting struct {
+	path  string //yaml keys joined by dots, also the flag name
+	env   string
+	value reflect.Value
+}
+
+//settings walks the yaml fields of v, lists of structs and maps are left to the file ...
+func settings(v reflect.Value, prefix string) []setting {
+	var found []setting
+
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		field := v.Field(i)
+		path := prefix + name
+
+		switch {
+		case field.Kind() == reflect.Struct:
+			found = append(found, settings(field, path+".")...)
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.String:
+		case field.Kind() == reflect.Map:
+		default:
+			env := envPrefix + strings.ToUpper(strings.Replace(path, ".", "_", -1))
+			found = append(found, setting{path: path, env: env, value: field})
+		}
+	}
+
+	return found
+}
+
+//set parses raw into the setting, lists are comma separated ...
+func (s setting) set(raw string) error {
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("invalid bool " + strconv.Quote(raw))
+		}
+		s.value.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("invalid number " + strconv.Quote(raw))
+		}
+		s.value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("invalid number " + strconv.Quote(raw))
+		}
+		s.value.SetFloat(f)
+	case reflect.Slice:
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		s.value.Set(reflect.ValueOf(values))
+	}
+
+	return nil
+}
+
+//flagValue only records the flag, LoadConfig applies it after the file and the environment ...
+type flagValue struct {
+	path    string
+	given   map[string]string
+	boolean bool
+}
+
+func (f flagValue) String() string { return "" }
+
+func (f flagValue) Set(raw string) error {
+	f.given[f.path] = raw
+	return nil
+}
+
+func (f flagValue) IsBoolFlag() bool { return f.boolean }
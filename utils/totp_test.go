@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+//rfcSecret is the SHA1 key of RFC 6238 Appendix B, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+//TestTOTPCodeRFC6238 checks the SHA1 vectors of RFC 6238 Appendix B, cut to the last 6 of their 8 digits
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestCheckTOTPSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		offset int64 //in steps from now
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := totpCode(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			step, ok := CheckTOTP(rfcSecret, code, now)
+			if ok != tt.ok {
+				t.Fatalf("CheckTOTP ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("CheckTOTP step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestCheckTOTPLength(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		code string
+		ok   bool
+	}{
+		{"287082", true},
+		{" 287082 ", true},
+		{"94287082", false}, //the full 8 digit code of the RFC
+		{"28708", false},
+		{"2870820", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := CheckTOTP(rfcSecret, tt.code, now); ok != tt.ok {
+			t.Errorf("CheckTOTP(%q) ok = %v, want %v", tt.code, ok, tt.ok)
+		}
+	}
+}
+
+func TestCheckTOTPBadSecret(t *testing.T) {
+	if _, ok := CheckTOTP("not base32!", "287082", time.Unix(59, 0)); ok {
+		t.Error("CheckTOTP accepted a code for an invalid secret")
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+
+	other, _ := NewTOTPSecret()
+	if other == secret {
+		t.Error("two secrets are equal")
+	}
+}
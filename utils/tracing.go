@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	gcontext "github.com/gorilla/context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//TraceConf selects the span exporter, none keeps tracing off ...
+type TraceConf struct {
+	Exporter    string  `yaml:"exporter"` //none, stdout, otlp-grpc or otlp-http
+	Endpoint    string  `yaml:"endpoint"` //collector address for otlp, host:port
+	Insecure    bool    `yaml:"insecure"` //plain text to a local collector
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+//InitTracer installs the global tracer provider and the W3C trace context propagator ...
+func InitTracer(conf TraceConf) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	ctx := context.Background()
+
+	switch conf.Exporter {
+	case "none", "":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp-grpc":
+		options := []otlptracegrpc.Option{}
+		if conf.Endpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(conf.Endpoint))
+		}
+		if conf.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case "otlp-http":
+		options := []otlptracehttp.Option{}
+		if conf.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(conf.Endpoint))
+		}
+		if conf.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, errors.New("Trace exporter must be none, stdout, otlp-grpc or otlp-http")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", conf.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+//Tracer ...
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/bicom/todos")
+}
+
+//RequestContext returns the context of the innermost running span of the request ...
+func RequestContext(r *http.Request) context.Context {
+	if ctx, ok := gcontext.Get(r, "ctx").(context.Context); ok {
+		return ctx
+	}
+
+	return r.Context()
+}
+
+//SetRequestContext keeps ctx for RequestContext, the request itself can't be replaced without losing its gorilla context ...
+func SetRequestContext(r *http.Request, ctx context.Context) {
+	gcontext.Set(r, "ctx", ctx)
+}